@@ -0,0 +1,140 @@
+package fpe
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Alphabet is an ordered set of characters (runes) used as the numerals of a
+// format-preserving cipher. The position of a character in the alphabet is its
+// numeral value, and the number of characters is the radix.
+// An Alphabet is immutable and safe for concurrent use.
+type Alphabet struct {
+	chars string
+	runes []rune
+	index map[rune]uint16
+}
+
+// Predefined alphabets.
+var (
+	// AlphabetNumeric is the decimal digits (radix 10).
+	AlphabetNumeric = mustAlphabet("0123456789")
+
+	// AlphabetAlphanumeric is digits followed by upper- and lowercase ASCII letters (radix 62).
+	AlphabetAlphanumeric = mustAlphabet("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+	// AlphabetUppercase is the uppercase ASCII letters (radix 26).
+	AlphabetUppercase = mustAlphabet("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	// AlphabetLowercase is the lowercase ASCII letters (radix 26).
+	AlphabetLowercase = mustAlphabet("abcdefghijklmnopqrstuvwxyz")
+
+	// AlphabetUppercaseAlphanumeric is digits followed by uppercase ASCII letters (radix 36).
+	AlphabetUppercaseAlphanumeric = mustAlphabet("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	// AlphabetLowercaseAlphanumeric is digits followed by lowercase ASCII letters (radix 36).
+	AlphabetLowercaseAlphanumeric = mustAlphabet("0123456789abcdefghijklmnopqrstuvwxyz")
+
+	// AlphabetHex is the lowercase hexadecimal digits (radix 16).
+	AlphabetHex = mustAlphabet("0123456789abcdef")
+
+	// AlphabetHexUpper is the uppercase hexadecimal digits (radix 16).
+	AlphabetHexUpper = mustAlphabet("0123456789ABCDEF")
+
+	// AlphabetBase32Crockford is Crockford's base32 alphabet, which omits I, L, O and U (radix 32).
+	AlphabetBase32Crockford = mustAlphabet("0123456789ABCDEFGHJKMNPQRSTVWXYZ")
+)
+
+// NewAlphabet creates an Alphabet from an ordered set of characters.
+// The characters must be valid UTF-8, contain no duplicates, and number
+// between 2 and 65536 (the radix range of FF1 and FF3-1).
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if !utf8.ValidString(chars) {
+		return nil, fmt.Errorf("alphabet is not valid UTF-8")
+	}
+
+	runes := []rune(chars)
+	if len(runes) < 2 {
+		return nil, fmt.Errorf("alphabet must contain at least 2 characters, got %d", len(runes))
+	}
+	if len(runes) > 1<<16 {
+		return nil, fmt.Errorf("alphabet must contain at most 65536 characters, got %d", len(runes))
+	}
+
+	index := make(map[rune]uint16, len(runes))
+	for i, r := range runes {
+		if _, ok := index[r]; ok {
+			return nil, fmt.Errorf("alphabet contains duplicate character %q", r)
+		}
+		index[r] = uint16(i)
+	}
+
+	return &Alphabet{
+		chars: chars,
+		runes: runes,
+		index: index,
+	}, nil
+}
+
+// mustAlphabet is NewAlphabet for the predefined alphabets; it panics on error.
+func mustAlphabet(chars string) *Alphabet {
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String returns the characters of the alphabet in order.
+func (a *Alphabet) String() string {
+	return a.chars
+}
+
+// Radix returns the number of characters in the alphabet.
+func (a *Alphabet) Radix() int {
+	return len(a.runes)
+}
+
+// Contains reports whether r is a character of the alphabet.
+func (a *Alphabet) Contains(r rune) bool {
+	_, ok := a.index[r]
+	return ok
+}
+
+// Index returns the numeral value of r and whether r is in the alphabet.
+func (a *Alphabet) Index(r rune) (uint16, bool) {
+	i, ok := a.index[r]
+	return i, ok
+}
+
+// Rune returns the character with numeral value i.
+func (a *Alphabet) Rune(i uint16) rune {
+	return a.runes[i]
+}
+
+// Encode converts a string of alphabet characters to numerals.
+// It returns an error if s contains a character outside the alphabet.
+func (a *Alphabet) Encode(s string) ([]uint16, error) {
+	numerals := make([]uint16, 0, len(s))
+	for _, r := range s {
+		i, ok := a.index[r]
+		if !ok {
+			return nil, fmt.Errorf("character %q is not in the alphabet", r)
+		}
+		numerals = append(numerals, i)
+	}
+	return numerals, nil
+}
+
+// Decode converts numerals back to a string of alphabet characters.
+// It returns an error if a numeral is not less than the radix.
+func (a *Alphabet) Decode(numerals []uint16) (string, error) {
+	runes := make([]rune, len(numerals))
+	for i, n := range numerals {
+		if int(n) >= len(a.runes) {
+			return "", fmt.Errorf("numeral %d is out of range for radix %d", n, len(a.runes))
+		}
+		runes[i] = a.runes[n]
+	}
+	return string(runes), nil
+}
@@ -0,0 +1,146 @@
+package fpe
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testKey is a 32-byte AES-256 key for tests.
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// TestNewAlphabetValidation verifies that invalid character sets are rejected.
+func TestNewAlphabetValidation(t *testing.T) {
+	testCases := []struct {
+		name  string
+		chars string
+		valid bool
+	}{
+		{"Hex", "0123456789abcdef", true},
+		{"Cyrillic", "АБВГДЕЖЗИЙКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ", true},
+		{"Empty", "", false},
+		{"SingleCharacter", "A", false},
+		{"Duplicate", "ABCA", false},
+		{"DuplicateRune", "ЖЖ", false},
+		{"InvalidUTF8", "ab\xff", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewAlphabet(tc.chars)
+			if tc.valid && err != nil {
+				t.Errorf("NewAlphabet(%q) failed: %v", tc.chars, err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("NewAlphabet(%q) should have failed", tc.chars)
+			}
+		})
+	}
+}
+
+// TestAlphabetEncodeDecode verifies rune-to-numeral lookup for multi-byte alphabets.
+func TestAlphabetEncodeDecode(t *testing.T) {
+	kana, err := NewAlphabet("アイウエオカキクケコ")
+	if err != nil {
+		t.Fatalf("NewAlphabet failed: %v", err)
+	}
+	if kana.Radix() != 10 {
+		t.Fatalf("Expected radix 10, got %d", kana.Radix())
+	}
+
+	numerals, err := kana.Encode("コアウ")
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if len(numerals) != 3 || numerals[0] != 9 || numerals[1] != 0 || numerals[2] != 2 {
+		t.Errorf("Unexpected numerals: %v", numerals)
+	}
+
+	s, err := kana.Decode(numerals)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if s != "コアウ" {
+		t.Errorf("Expected コアウ, got %s", s)
+	}
+
+	if _, err := kana.Encode("アX"); err == nil {
+		t.Error("Expected Encode to reject a character outside the alphabet")
+	}
+	if _, err := kana.Decode([]uint16{10}); err == nil {
+		t.Error("Expected Decode to reject a numeral >= radix")
+	}
+}
+
+// TestTokenizerWithAlphabets verifies round trips over custom and Unicode alphabets
+// and that characters outside the alphabet are preserved.
+func TestTokenizerWithAlphabets(t *testing.T) {
+	cyrillic, err := NewAlphabet("АБВГДЕЖЗИЙКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ")
+	if err != nil {
+		t.Fatalf("NewAlphabet failed: %v", err)
+	}
+	kana, err := NewAlphabet("アイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワヲン")
+	if err != nil {
+		t.Fatalf("NewAlphabet failed: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		alphabet  *Alphabet
+		plaintext string
+	}{
+		{"ProductCode", AlphabetUppercaseAlphanumeric, "PRD-7X9K2Q"},
+		{"Hex", AlphabetHex, "deadbeef-0042"},
+		{"Crockford", AlphabetBase32Crockford, "7ZQ4-M9XA"},
+		{"Cyrillic", cyrillic, "ИВАНОВ-ПЁТР"},
+		{"Kana", kana, "ヤマダ・タロウ"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("tweak"), WithAlphabet(tc.alphabet))
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+
+			tokenized, err := tokenizer.Tokenize(tc.plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if utf8.RuneCountInString(tokenized) != utf8.RuneCountInString(tc.plaintext) {
+				t.Errorf("Length not preserved: %q -> %q", tc.plaintext, tokenized)
+			}
+
+			for i, r := range []rune(tokenized) {
+				original := []rune(tc.plaintext)[i]
+				if tc.alphabet.Contains(original) != tc.alphabet.Contains(r) {
+					t.Errorf("Character class changed at %d: %q -> %q", i, original, r)
+				}
+				if !tc.alphabet.Contains(original) && original != r {
+					t.Errorf("Format character changed at %d: %q -> %q", i, original, r)
+				}
+			}
+
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != tc.plaintext {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", tc.plaintext, tokenized, detokenized)
+			}
+		})
+	}
+
+	// Uppercase product codes must never gain lowercase letters
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithAlphabet(AlphabetUppercaseAlphanumeric))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	tokenized, err := tokenizer.Tokenize("AB12CD34")
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if strings.ToUpper(tokenized) != tokenized {
+		t.Errorf("Uppercase alphabet produced lowercase characters: %s", tokenized)
+	}
+}
@@ -0,0 +1,158 @@
+package fpe
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestEncryptBytes verifies that byte strings round-trip with the same length
+// for both algorithms, and that every 2-byte value maps to a distinct value.
+func TestEncryptBytes(t *testing.T) {
+	ff1, err := NewFF1(testKey, []byte("device-id"))
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+	ff31, err := NewFF31(testKey, []byte("tweak-7"))
+	if err != nil {
+		t.Fatalf("NewFF31 failed: %v", err)
+	}
+
+	values := [][]byte{
+		{0x00, 0x00, 0x00},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		[]byte("0123456789abcdef"),
+		bytes.Repeat([]byte{0xa5}, 24),
+	}
+	for _, c := range []struct {
+		name string
+		fpe  BytesFPE
+	}{{"FF1", ff1}, {"FF3-1", ff31}} {
+		t.Run(c.name, func(t *testing.T) {
+			for _, v := range values {
+				token, err := c.fpe.EncryptBytes(v)
+				if err != nil {
+					t.Fatalf("EncryptBytes(%x) failed: %v", v, err)
+				}
+				if len(token) != len(v) {
+					t.Errorf("EncryptBytes(%x) = %x has length %d, expected %d", v, token, len(token), len(v))
+				}
+				if bytes.Equal(token, v) {
+					t.Errorf("EncryptBytes(%x) returned the input", v)
+				}
+				back, err := c.fpe.DecryptBytes(token)
+				if err != nil {
+					t.Fatalf("DecryptBytes(%x) failed: %v", token, err)
+				}
+				if !bytes.Equal(back, v) {
+					t.Errorf("Round-trip failed: %x -> %x -> %x", v, token, back)
+				}
+			}
+		})
+	}
+
+	seen := make(map[[2]byte]bool)
+	for i := 0; i < 1<<16; i++ {
+		token, err := ff1.EncryptBytes([]byte{byte(i >> 8), byte(i)})
+		if err != nil {
+			t.Fatalf("EncryptBytes failed: %v", err)
+		}
+		key := [2]byte{token[0], token[1]}
+		if seen[key] {
+			t.Fatalf("EncryptBytes is not a permutation: %x repeated", token)
+		}
+		seen[key] = true
+	}
+}
+
+// TestEncryptBits verifies bit-level encryption of lengths that are not a
+// whole number of bytes.
+func TestEncryptBits(t *testing.T) {
+	f, err := NewFF1(testKey, []byte("bits"))
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		bitLen int
+	}{
+		{"10Bits", []byte{0xab, 0xc0}, 10},
+		{"12Bits", []byte{0xff, 0xf0}, 12},
+		{"60Bits", []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xe0}, 60},
+		{"64Bits", []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}, 64},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := f.EncryptBits(tc.data, tc.bitLen)
+			if err != nil {
+				t.Fatalf("EncryptBits failed: %v", err)
+			}
+			if len(token) != len(tc.data) {
+				t.Errorf("EncryptBits returned %d bytes, expected %d", len(token), len(tc.data))
+			}
+			if rest := tc.bitLen % 8; rest != 0 && token[len(token)-1]&(0xff>>rest) != 0 {
+				t.Errorf("EncryptBits(%x) = %x sets unused trailing bits", tc.data, token)
+			}
+			back, err := f.DecryptBits(token, tc.bitLen)
+			if err != nil {
+				t.Fatalf("DecryptBits failed: %v", err)
+			}
+			if !bytes.Equal(back, tc.data) {
+				t.Errorf("Round-trip failed: %x -> %x -> %x", tc.data, token, back)
+			}
+		})
+	}
+
+	// The bit and byte APIs are distinct permutations
+	data := []byte{0x01, 0x23, 0x45, 0x67}
+	byBits, err := f.EncryptBits(data, 32)
+	if err != nil {
+		t.Fatalf("EncryptBits failed: %v", err)
+	}
+	byBytes, err := f.EncryptBytes(data)
+	if err != nil {
+		t.Fatalf("EncryptBytes failed: %v", err)
+	}
+	if bytes.Equal(byBits, byBytes) {
+		t.Errorf("EncryptBits and EncryptBytes produced the same ciphertext %x", byBits)
+	}
+}
+
+// TestEncryptBytesErrors verifies that inputs outside the cipher's domain and
+// malformed bit strings are rejected.
+func TestEncryptBytesErrors(t *testing.T) {
+	ff1, err := NewFF1(testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+	ff31, err := NewFF31(testKey, []byte("tweak-7"))
+	if err != nil {
+		t.Fatalf("NewFF31 failed: %v", err)
+	}
+	legacy, err := NewFF1Legacy(testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFF1Legacy failed: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		fn   func() ([]byte, error)
+	}{
+		{"OneByte", func() ([]byte, error) { return ff1.EncryptBytes([]byte{7}) }},
+		{"FF31TwoBytes", func() ([]byte, error) { return ff31.EncryptBytes([]byte{7, 7}) }},
+		{"FF31TooLong", func() ([]byte, error) { return ff31.EncryptBytes(make([]byte, 25)) }},
+		{"TooFewBits", func() ([]byte, error) { return ff1.EncryptBits([]byte{0xc0}, 2) }},
+		{"WrongByteCount", func() ([]byte, error) { return ff1.EncryptBits([]byte{1, 2, 3}, 16) }},
+		{"TrailingBitsSet", func() ([]byte, error) { return ff1.EncryptBits([]byte{0xff, 0xff}, 12) }},
+		{"NegativeBitLength", func() ([]byte, error) { return ff1.EncryptBits(nil, -1) }},
+		{"Legacy", func() ([]byte, error) { return legacy.EncryptBytes([]byte{1, 2, 3, 4}) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if out, err := tc.fn(); err == nil {
+				t.Errorf("Expected an error, got %x", out)
+			}
+		})
+	}
+}
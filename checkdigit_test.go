@@ -0,0 +1,138 @@
+package fpe
+
+import (
+	"testing"
+)
+
+// TestCheckDigitAlgorithms verifies the predefined algorithms against known
+// check digits.
+func TestCheckDigitAlgorithms(t *testing.T) {
+	testCases := []struct {
+		algorithm CheckDigit
+		payload   string
+		check     string
+	}{
+		{CheckDigitLuhn, "7992739871", "3"},
+		{CheckDigitLuhn, "411111111111111", "1"},
+		{CheckDigitLuhn, "37828224631000", "5"},
+		{CheckDigitMod97, "370400440532013000DE", "89"},
+		{CheckDigitMod97, "WEST12345698765432GB", "82"},
+		{CheckDigitMod97, "1234567890", "92"},
+		{CheckDigitVerhoeff, "236", "3"},
+		{CheckDigitVerhoeff, "12345", "1"},
+		{CheckDigitVerhoeff, "142857", "0"},
+		{CheckDigitDamm, "572", "4"},
+		{CheckDigitDamm, "12345", "9"},
+	}
+
+	for _, tc := range testCases {
+		check, err := tc.algorithm.Compute(tc.payload)
+		if err != nil {
+			t.Fatalf("%v.Compute(%s) failed: %v", tc.algorithm, tc.payload, err)
+		}
+		if check != tc.check {
+			t.Errorf("%v.Compute(%s) = %s, want %s", tc.algorithm, tc.payload, check, tc.check)
+		}
+	}
+
+	for _, algorithm := range []CheckDigit{CheckDigitLuhn, CheckDigitVerhoeff, CheckDigitDamm} {
+		if _, err := algorithm.Compute("12a4"); err == nil {
+			t.Errorf("Expected %v to reject a non-digit payload", algorithm)
+		}
+	}
+	if _, err := CheckDigitMod97.Compute("12-4"); err == nil {
+		t.Error("Expected Mod97 to reject a payload with a symbol")
+	}
+}
+
+// TestCheckDigitTokenization verifies that tokens carry recomputed check
+// digits in every tokenizer mode and that they round-trip.
+func TestCheckDigitTokenization(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		opts  []Option
+	}{
+		{"Luhn", "4111-1111-1111-1111", []Option{WithCheckDigit(CheckDigitLuhn)}},
+		{"LuhnRevealBIN", "4111111111111111", []Option{WithCheckDigit(CheckDigitLuhn), WithRevealPrefix(6)}},
+		{"Verhoeff", "1234 5678 9012 0", []Option{WithCheckDigit(CheckDigitVerhoeff)}},
+		{"Damm", "000-572-4", []Option{WithAlphabet(AlphabetNumeric), WithCheckDigit(CheckDigitDamm)}},
+		{"Mod97Format", "AB-1234567890-79", []Option{WithFormat(MustParseFormat("AA-##########-##")), WithCheckDigit(CheckDigitMod97)}},
+		{"Mod97Classes", "XY1234567890-38", []Option{WithClassPreservation(), WithCheckDigit(CheckDigitMod97)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("check"), tc.opts...)
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+			alg := tokenizer.config.checkDigit
+
+			payload, check := splitCheckDigits(tc.value, alg.Size())
+			if want, _ := alg.Compute(payload); want != check {
+				t.Fatalf("Test value %s has wrong check digits", tc.value)
+			}
+
+			tokenized, err := tokenizer.Tokenize(tc.value)
+			if err != nil {
+				t.Fatalf("Tokenize(%s) failed: %v", tc.value, err)
+			}
+			if tokenized == tc.value || len(tokenized) != len(tc.value) {
+				t.Errorf("Bad token for %s: %s", tc.value, tokenized)
+			}
+			payload, check = splitCheckDigits(tokenized, alg.Size())
+			if want, err := alg.Compute(payload); err != nil || want != check {
+				t.Errorf("Token %s does not pass the %v check", tokenized, alg)
+			}
+
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+			}
+			if detokenized != tc.value {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", tc.value, tokenized, detokenized)
+			}
+		})
+	}
+}
+
+// TestCheckDigitValidation verifies that wrong check digits and conflicting
+// options are rejected.
+func TestCheckDigitValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithCheckDigit(CheckDigitLuhn))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := tokenizer.Tokenize("4111111111111112"); err == nil {
+		t.Error("Expected an error for a wrong check digit")
+	}
+	if _, err := tokenizer.Tokenize("4"); err == nil {
+		t.Error("Expected an error for a value without a payload")
+	}
+
+	invalid := [][]Option{
+		{WithCheckDigit(nil)},
+		{WithCheckDigit(CheckDigitLuhn), WithLuhn(LuhnValid)},
+		{WithCheckDigit(CheckDigitLuhn), WithRevealSuffix(4)},
+		{WithCheckDigit(CheckDigitLuhn), WithDate(DateLayoutISO)},
+		{WithCheckDigit(CheckDigitMod97), WithIBAN()},
+	}
+	for i, opts := range invalid {
+		if _, err := NewFF1Tokenizer(testKey, nil, opts...); err == nil {
+			t.Errorf("Expected option set %d to be rejected", i)
+		}
+	}
+}
+
+// splitCheckDigits returns the alphanumeric characters of s without the last
+// n, and the last n.
+func splitCheckDigits(s string, n int) (payload, check string) {
+	var data []rune
+	for _, r := range s {
+		if isASCIIAlphanumeric(r) {
+			data = append(data, r)
+		}
+	}
+	return string(data[:len(data)-n]), string(data[len(data)-n:])
+}
@@ -0,0 +1,67 @@
+package fpe
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// cipherClasses encrypts (or decrypts) data, where data[i] is a character of
+// classes[i], so that every position keeps its class.
+//
+// The data characters are read as one mixed-radix integer, where each position
+// has the radix of its class: "A1b" is the value A*10*26 + 1*26 + b in a domain
+// of 26*10*26 values. That value is encrypted with subtle.EncryptRange so the
+// result stays in the same domain, and is then written back with the same radix
+// per position.
+func cipherClasses(c subtle.Cipher, classes []*Alphabet, data []rune, encrypt bool) ([]rune, error) {
+	// EncryptRange accepts ranges below the cipher's minimum domain by widening
+	// them, so check the real domain here.
+	if err := checkDomain(c, classes); err != nil {
+		return nil, err
+	}
+
+	value := new(big.Int)
+	domain := big.NewInt(1)
+	for i, class := range classes {
+		numeral, ok := class.Index(data[i])
+		if !ok {
+			return nil, fmt.Errorf("character %q is not in its class", data[i])
+		}
+		radix := big.NewInt(int64(class.Radix()))
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(numeral)))
+		domain.Mul(domain, radix)
+	}
+
+	var result *big.Int
+	var err error
+	last := new(big.Int).Sub(domain, big.NewInt(1))
+	if encrypt {
+		result, err = subtle.EncryptRange(c, value, big.NewInt(0), last)
+	} else {
+		result, err = subtle.DecryptRange(c, value, big.NewInt(0), last)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]rune, len(classes))
+	numeral := new(big.Int)
+	for i := len(classes) - 1; i >= 0; i-- {
+		result.DivMod(result, big.NewInt(int64(classes[i].Radix())), numeral)
+		out[i] = classes[i].Rune(uint16(numeral.Uint64()))
+	}
+	return out, nil
+}
+
+// classify returns the class containing r.
+func (t *Tokenizer) classify(r rune) (*Alphabet, bool) {
+	for _, class := range t.config.classes {
+		if class.Contains(r) {
+			return class, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,107 @@
+package fpe
+
+import (
+	"testing"
+)
+
+// TestClassPreservation verifies that every position keeps its character class
+// and that the token round-trips without the original plaintext.
+func TestClassPreservation(t *testing.T) {
+	f, err := NewFF1(testKey, []byte("tweak"), WithClassPreservation())
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+
+	testCases := []string{
+		"AB12cd",
+		"ab-1234",
+		"XY9999ZZ",
+		"Order 42: Kx7Q-m3p",
+		"00000",
+		"zzzz",
+	}
+
+	for _, plaintext := range testCases {
+		t.Run(plaintext, func(t *testing.T) {
+			tokenized, err := f.Tokenize(plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if len(tokenized) != len(plaintext) {
+				t.Fatalf("Length not preserved: %q -> %q", plaintext, tokenized)
+			}
+			for i := range plaintext {
+				if characterClass(plaintext[i]) != characterClass(tokenized[i]) {
+					t.Errorf("Class changed at %d: %q -> %q", i, plaintext, tokenized)
+				}
+			}
+
+			detokenized, err := f.Detokenize(tokenized, "", "")
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != plaintext {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", plaintext, tokenized, detokenized)
+			}
+		})
+	}
+}
+
+// TestClassPreservationDomain verifies that tokens cover the whole mixed-radix
+// domain: every digit position can change, not just the low-order ones.
+func TestClassPreservationDomain(t *testing.T) {
+	f, err := NewFF1Tokenizer(testKey, nil, WithClassPreservation())
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	seen := make(map[byte]bool)
+	for _, plaintext := range []string{"A0000", "A0001", "A0002", "A0003", "A0004", "A0005", "A0006", "A0007"} {
+		tokenized, err := f.Tokenize(plaintext)
+		if err != nil {
+			t.Fatalf("Tokenize failed: %v", err)
+		}
+		seen[tokenized[0]] = true
+	}
+	if len(seen) < 2 {
+		t.Error("The leading letter never changed; the mixed-radix value is not fully mixed")
+	}
+}
+
+// TestCharacterClassesValidation verifies option validation.
+func TestCharacterClassesValidation(t *testing.T) {
+	if _, err := NewFF1Tokenizer(testKey, nil, WithCharacterClasses()); err == nil {
+		t.Error("Expected an error for no classes")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithCharacterClasses(AlphabetNumeric, nil)); err == nil {
+		t.Error("Expected an error for a nil class")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithCharacterClasses(AlphabetHex, AlphabetLowercase)); err == nil {
+		t.Error("Expected an error for overlapping classes")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithClassPreservation(), WithAlphabet(AlphabetNumeric)); err == nil {
+		t.Error("Expected an error when combining WithAlphabet and WithCharacterClasses")
+	}
+
+	f, err := NewFF1Tokenizer(testKey, nil, WithClassPreservation())
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := f.Tokenize("A1"); err == nil {
+		t.Error("Expected an error for a domain that is too small")
+	}
+}
+
+// characterClass returns 'd', 'u', 'l' or the byte itself for format characters.
+func characterClass(b byte) byte {
+	switch {
+	case b >= '0' && b <= '9':
+		return 'd'
+	case b >= 'A' && b <= 'Z':
+		return 'u'
+	case b >= 'a' && b <= 'z':
+		return 'l'
+	default:
+		return b
+	}
+}
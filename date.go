@@ -0,0 +1,250 @@
+package fpe
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// Common date layouts for WithDate, in the notation of the time package.
+const (
+	// DateLayoutISO is the ISO 8601 calendar date, e.g. "2024-03-15".
+	DateLayoutISO = "2006-01-02"
+
+	// DateLayoutUS is month/day/year, e.g. "03/15/2024".
+	DateLayoutUS = "01/02/2006"
+
+	// DateLayoutEU is day/month/year, e.g. "15/03/2024".
+	DateLayoutEU = "02/01/2006"
+)
+
+// Default date range used by WithDate.
+var (
+	defaultDateMin = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+	defaultDateMax = time.Date(2099, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// secondsPerDay converts between Unix time and day numbers.
+const secondsPerDay = 24 * 60 * 60
+
+// WithDate makes the Tokenizer treat every value as a calendar date in layout
+// (see DateLayoutISO, DateLayoutUS, DateLayoutEU, or any layout accepted by
+// time.Parse). A date is mapped to another valid date within the date range,
+// 1900-01-01 to 2099-12-31 unless changed with WithDateRange, and written in
+// the same layout, so "2024-03-15" never becomes "9471-83-52". Any time of day
+// in the layout is preserved.
+//
+// The layout must hold the whole date: a year, a month and a day of the month.
+// Layouts with a 2-digit year ("06") only hold the years 1969 to 2068, so they
+// need WithDateRange within those years.
+func WithDate(layout string) Option {
+	return func(c *config) error {
+		if layout == "" {
+			return fmt.Errorf("date layout cannot be empty")
+		}
+		if err := c.setMode(modeDate); err != nil {
+			return err
+		}
+		c.dateLayout = layout
+		return nil
+	}
+}
+
+// WithDateRange sets the inclusive range of dates for WithDate. Only the
+// calendar dates of min and max are used.
+func WithDateRange(min, max time.Time) Option {
+	return func(c *config) error {
+		min, max = calendarDate(min), calendarDate(max)
+		if min.After(max) {
+			return fmt.Errorf("invalid date range: %s is after %s", min.Format(DateLayoutISO), max.Format(DateLayoutISO))
+		}
+		c.dateMin, c.dateMax = min, max
+		return nil
+	}
+}
+
+// WithPreserveYear keeps the year of a date for WithDate; only the month and
+// day are tokenized.
+func WithPreserveYear() Option {
+	return func(c *config) error {
+		c.preserveYear = true
+		return nil
+	}
+}
+
+// WithPreserveMonth keeps the month of a date for WithDate. Combined with
+// WithPreserveYear only the day is tokenized, which is a domain of at most 31
+// values and therefore only possible with FF1.
+func WithPreserveMonth() Option {
+	return func(c *config) error {
+		c.preserveMonth = true
+		return nil
+	}
+}
+
+// TokenizeTime tokenizes the calendar date of t, keeping its time of day and
+// location. The Tokenizer must have been created with WithDate.
+func (t *Tokenizer) TokenizeTime(tm time.Time) (time.Time, error) {
+	if t.config.mode != modeDate {
+		return time.Time{}, fmt.Errorf("failed to tokenize: Tokenizer was not created with WithDate")
+	}
+	tokenized, err := t.transformTime(tm, true)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to tokenize: %w", err)
+	}
+	return tokenized, nil
+}
+
+// DetokenizeTime inverts TokenizeTime.
+func (t *Tokenizer) DetokenizeTime(tm time.Time) (time.Time, error) {
+	if t.config.mode != modeDate {
+		return time.Time{}, fmt.Errorf("failed to detokenize: Tokenizer was not created with WithDate")
+	}
+	plaintext, err := t.transformTime(tm, false)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to detokenize: %w", err)
+	}
+	return plaintext, nil
+}
+
+// checkDateLayout checks that layout holds every date from min to max, so that
+// every token parses back to the date it was formatted from.
+func checkDateLayout(layout string, min, max time.Time) error {
+	// December 31 has a day and month other than 1, so a layout missing
+	// either does not give it back; the 2-digit year window is contiguous,
+	// so holding the years of min and max means holding every year between
+	for _, date := range []time.Time{min, max, time.Date(min.Year(), time.December, 31, 0, 0, 0, 0, time.UTC)} {
+		parsed, err := time.Parse(layout, date.Format(layout))
+		if err != nil || !calendarDate(parsed).Equal(date) {
+			return fmt.Errorf("date layout %q cannot hold the date %s: it needs a year, a month and a day, and 2-digit years only hold 1969 to 2068", layout, date.Format(DateLayoutISO))
+		}
+	}
+	return nil
+}
+
+// transformDate tokenizes (encrypt) or detokenizes s under WithDate.
+func (t *Tokenizer) transformDate(s string, encrypt bool) (string, error) {
+	layout := t.config.dateLayout
+	tm, err := time.Parse(layout, s)
+	if err != nil {
+		return "", fmt.Errorf("value %q does not match date layout %q: %w", s, layout, err)
+	}
+	result, err := t.transformTime(tm, encrypt)
+	if err != nil {
+		return "", err
+	}
+	return result.Format(layout), nil
+}
+
+// transformTime maps the calendar date of tm to another date that shares the
+// preserved fields, keeping the time of day and location.
+//
+// The dates in range that share the preserved fields are numbered in order,
+// the number of tm's date is encrypted with subtle.EncryptRange, and the date
+// with the resulting number is returned.
+func (t *Tokenizer) transformTime(tm time.Time, encrypt bool) (time.Time, error) {
+	c := t.config
+	year, month, day := tm.Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Before(c.dateMin) || date.After(c.dateMax) {
+		return time.Time{}, fmt.Errorf("date %s is outside the range %s to %s", date.Format(DateLayoutISO), c.dateMin.Format(DateLayoutISO), c.dateMax.Format(DateLayoutISO))
+	}
+
+	blocks := t.dateBlocks(year, month)
+	rank, size := int64(0), int64(0)
+	target := unixDay(date)
+	for _, b := range blocks {
+		if target >= b.first && target <= b.last {
+			rank = size + target - b.first
+		}
+		size += b.last - b.first + 1
+	}
+
+	var result *big.Int
+	var err error
+	if encrypt {
+		result, err = subtle.EncryptRange(t.cipher, big.NewInt(rank), big.NewInt(0), big.NewInt(size-1))
+	} else {
+		result, err = subtle.DecryptRange(t.cipher, big.NewInt(rank), big.NewInt(0), big.NewInt(size-1))
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	rank = result.Int64()
+	for _, b := range blocks {
+		if n := b.last - b.first + 1; rank >= n {
+			rank -= n
+			continue
+		}
+		y, m, d := time.Unix((b.first+rank)*secondsPerDay, 0).UTC().Date()
+		return time.Date(y, m, d, tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), tm.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("date number %s is out of range", result.String())
+}
+
+// dayBlock is an inclusive run of consecutive Unix day numbers.
+type dayBlock struct {
+	first, last int64
+}
+
+// dateBlocks returns, in order, the days in the date range that share the
+// preserved fields of year and month.
+func (t *Tokenizer) dateBlocks(year int, month time.Month) []dayBlock {
+	c := t.config
+	minDay, maxDay := unixDay(c.dateMin), unixDay(c.dateMax)
+
+	var blocks []dayBlock
+	add := func(first, last time.Time) {
+		b := dayBlock{first: unixDay(first), last: unixDay(last)}
+		if b.first < minDay {
+			b.first = minDay
+		}
+		if b.last > maxDay {
+			b.last = maxDay
+		}
+		if b.first <= b.last {
+			blocks = append(blocks, b)
+		}
+	}
+	monthStart := func(y int, m time.Month) time.Time {
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	switch {
+	case c.preserveYear && c.preserveMonth:
+		add(monthStart(year, month), monthStart(year, month+1).AddDate(0, 0, -1))
+	case c.preserveYear:
+		add(monthStart(year, time.January), monthStart(year+1, time.January).AddDate(0, 0, -1))
+	case c.preserveMonth:
+		for y := c.dateMin.Year(); y <= c.dateMax.Year(); y++ {
+			add(monthStart(y, month), monthStart(y, month+1).AddDate(0, 0, -1))
+		}
+	default:
+		add(c.dateMin, c.dateMax)
+	}
+	return blocks
+}
+
+// calendarDate returns the calendar date of t at midnight UTC.
+func calendarDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// unixDay returns the number of days between the Unix epoch and date, which
+// must be midnight UTC.
+func unixDay(date time.Time) int64 {
+	return floorDiv(date.Unix(), secondsPerDay)
+}
+
+// floorDiv divides rounding towards negative infinity, for dates before 1970.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,178 @@
+package fpe
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDateTokenization verifies that tokens are valid dates in the same layout
+// and range, that preserved fields are kept, and that tokens round-trip.
+func TestDateTokenization(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []Option
+		plaintext string
+		check     func(plain, token time.Time) bool
+	}{
+		{"ISO", []Option{WithDate(DateLayoutISO)}, "2024-03-15", nil},
+		{"US", []Option{WithDate(DateLayoutUS)}, "03/15/2024", nil},
+		{"EU", []Option{WithDate(DateLayoutEU)}, "29/02/2000", nil},
+		{"DateTime", []Option{WithDate("2006-01-02 15:04:05")}, "1999-12-31 23:59:58", func(p, tk time.Time) bool {
+			return p.Hour() == tk.Hour() && p.Minute() == tk.Minute() && p.Second() == tk.Second()
+		}},
+		{"PreserveYear", []Option{WithDate(DateLayoutISO), WithPreserveYear()}, "1985-07-04", func(p, tk time.Time) bool {
+			return p.Year() == tk.Year()
+		}},
+		{"PreserveMonth", []Option{WithDate(DateLayoutISO), WithPreserveMonth()}, "1985-02-28", func(p, tk time.Time) bool {
+			return p.Month() == tk.Month()
+		}},
+		{"PreserveYearAndMonth", []Option{WithDate(DateLayoutISO), WithPreserveYear(), WithPreserveMonth()}, "2024-02-29", func(p, tk time.Time) bool {
+			return p.Year() == tk.Year() && p.Month() == tk.Month()
+		}},
+		{"CustomRange", []Option{WithDate(DateLayoutISO), WithDateRange(
+			time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+		)}, "2025-06-30", func(p, tk time.Time) bool {
+			return !tk.Before(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)) &&
+				!tk.After(time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC))
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("dob"), tc.opts...)
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+			layout := tokenizer.config.dateLayout
+
+			tokenized, err := tokenizer.Tokenize(tc.plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			tokenDate, err := time.Parse(layout, tokenized)
+			if err != nil {
+				t.Fatalf("Token %q is not a valid date: %v", tokenized, err)
+			}
+			if tokenized == tc.plaintext {
+				t.Errorf("Date was not tokenized: %s", tokenized)
+			}
+			plainDate, _ := time.Parse(layout, tc.plaintext)
+			if tc.check != nil && !tc.check(plainDate, tokenDate) {
+				t.Errorf("Token %s does not keep the expected fields of %s", tokenized, tc.plaintext)
+			}
+			if tokenDate.Year() < 1900 || tokenDate.Year() > 2099 {
+				t.Errorf("Token %s is outside the default range", tokenized)
+			}
+
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != tc.plaintext {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", tc.plaintext, tokenized, detokenized)
+			}
+		})
+	}
+}
+
+// TestDateTokenizationTime verifies the time.Time API keeps clock and location.
+func TestDateTokenizationTime(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithDate(DateLayoutISO))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	original := time.Date(1970, time.January, 1, 3, 4, 5, 6, loc)
+	tokenized, err := tokenizer.TokenizeTime(original)
+	if err != nil {
+		t.Fatalf("TokenizeTime failed: %v", err)
+	}
+	if tokenized.Location() != loc || tokenized.Hour() != 3 || tokenized.Nanosecond() != 6 {
+		t.Errorf("Time of day or location not preserved: %v -> %v", original, tokenized)
+	}
+
+	detokenized, err := tokenizer.DetokenizeTime(tokenized)
+	if err != nil {
+		t.Fatalf("DetokenizeTime failed: %v", err)
+	}
+	if !detokenized.Equal(original) {
+		t.Errorf("Round-trip failed: %v -> %v -> %v", original, tokenized, detokenized)
+	}
+
+	plain, err := NewFF1Tokenizer(testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := plain.TokenizeTime(original); err == nil {
+		t.Error("Expected TokenizeTime to require WithDate")
+	}
+}
+
+// TestDateTokenizationErrors verifies invalid dates and options are rejected.
+func TestDateTokenizationErrors(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithDate(DateLayoutISO))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	for _, value := range []string{"2024-02-30", "2024-13-01", "15/03/2024", "1899-12-31", "2100-01-01"} {
+		if _, err := tokenizer.Tokenize(value); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", value)
+		}
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithPreserveYear()); err == nil {
+		t.Error("Expected WithPreserveYear to require WithDate")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithDate(DateLayoutISO), WithAlphabet(AlphabetNumeric)); err == nil {
+		t.Error("Expected WithDate and WithAlphabet to be exclusive")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithDate(DateLayoutISO), WithDateRange(
+		time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	)); err == nil {
+		t.Error("Expected an error for an inverted date range")
+	}
+
+	// Layouts must hold every date of the range
+	for _, layout := range []string{"01/02/06", "Jan 2006", "01/2006", "02.01.", "2006-01"} {
+		if _, err := NewFF1Tokenizer(testKey, nil, WithDate(layout)); err == nil {
+			t.Errorf("Expected layout %q to be rejected", layout)
+		}
+	}
+}
+
+// TestDateTokenizationTwoDigitYear verifies that a 2-digit year layout
+// round-trips within the years it can hold.
+func TestDateTokenizationTwoDigitYear(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithDate("01/02/06"), WithDateRange(
+		time.Date(1969, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2068, time.December, 31, 0, 0, 0, 0, time.UTC),
+	))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	date := time.Date(1969, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 400; i++ {
+		value := date.AddDate(0, 0, i*91).Format("01/02/06")
+		tokenized, err := tokenizer.Tokenize(value)
+		if err != nil {
+			t.Fatalf("Tokenize(%s) failed: %v", value, err)
+		}
+		detokenized, err := tokenizer.Detokenize(tokenized)
+		if err != nil {
+			t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+		}
+		if detokenized != value {
+			t.Errorf("Round-trip failed: %s -> %s -> %s", value, tokenized, detokenized)
+		}
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithDate("01/02/06"), WithDateRange(
+		time.Date(1950, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2000, time.December, 31, 0, 0, 0, 0, time.UTC),
+	)); err == nil {
+		t.Error("Expected a 2-digit year layout to be rejected for years before 1969")
+	}
+}
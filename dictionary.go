@@ -0,0 +1,271 @@
+package fpe
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// Dictionary is an ordered list of words, such as first names or cities, that
+// WithDictionary tokenizes within: every word maps to another word of the list.
+// Words are matched case-insensitively. A Dictionary is immutable and safe for
+// concurrent use.
+type Dictionary struct {
+	words []string
+	index map[string]int
+}
+
+// NewDictionary creates a Dictionary from an ordered list of words. There must
+// be at least 2 words, all non-empty valid UTF-8, and no two may differ only in
+// case. The order matters: the same value tokenizes differently under a
+// reordered list.
+func NewDictionary(words []string) (*Dictionary, error) {
+	if len(words) < 2 {
+		return nil, fmt.Errorf("dictionary must contain at least 2 words, got %d", len(words))
+	}
+	d := &Dictionary{
+		words: make([]string, len(words)),
+		index: make(map[string]int, len(words)),
+	}
+	for i, w := range words {
+		if w == "" || !utf8.ValidString(w) {
+			return nil, fmt.Errorf("dictionary word %d is empty or not valid UTF-8", i)
+		}
+		key := strings.ToLower(w)
+		if j, ok := d.index[key]; ok {
+			return nil, fmt.Errorf("dictionary words %d (%q) and %d (%q) differ only in case", j, words[j], i, w)
+		}
+		d.words[i] = w
+		d.index[key] = i
+	}
+	return d, nil
+}
+
+// LoadDictionary reads a Dictionary from the file name in fsys, which may be an
+// embed.FS. The file has one word per line; surrounding whitespace is trimmed,
+// and blank lines and lines starting with '#' are skipped.
+func LoadDictionary(fsys fs.FS, name string) (*Dictionary, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	return parseDictionary(data)
+}
+
+// LoadDictionaryFile reads a Dictionary from the file at path, in the format
+// of LoadDictionary.
+func LoadDictionaryFile(path string) (*Dictionary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	return parseDictionary(data)
+}
+
+// parseDictionary parses the file format of LoadDictionary.
+func parseDictionary(data []byte) (*Dictionary, error) {
+	var words []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+	return NewDictionary(words)
+}
+
+// Len returns the number of words.
+func (d *Dictionary) Len() int {
+	return len(d.words)
+}
+
+// Word returns the word with rank i, as spelled in the list.
+func (d *Dictionary) Word(i int) string {
+	return d.words[i]
+}
+
+// Rank returns the position of word in the list, ignoring case, and whether
+// it is in the list at all.
+func (d *Dictionary) Rank(word string) (int, bool) {
+	i, ok := d.index[strings.ToLower(word)]
+	return i, ok
+}
+
+// DictionaryMissPolicy selects what WithDictionary does with values that are
+// not in the dictionary.
+type DictionaryMissPolicy int
+
+const (
+	// DictionaryMissError rejects values that are not in the dictionary. This
+	// is the default.
+	DictionaryMissError DictionaryMissPolicy = iota
+
+	// DictionaryMissFallback tokenizes values that are not in the dictionary
+	// like WithClassPreservation, cycle walking until the token is not a
+	// dictionary word either, so Detokenize can tell the two apart.
+	DictionaryMissFallback
+
+	// DictionaryMissPassThrough returns values that are not in the dictionary
+	// unchanged.
+	DictionaryMissPassThrough
+)
+
+// String returns the name of the policy.
+func (p DictionaryMissPolicy) String() string {
+	switch p {
+	case DictionaryMissError:
+		return "Error"
+	case DictionaryMissFallback:
+		return "Fallback"
+	case DictionaryMissPassThrough:
+		return "PassThrough"
+	default:
+		return fmt.Sprintf("DictionaryMissPolicy(%d)", int(p))
+	}
+}
+
+// WithDictionary makes the Tokenizer map every word of dictionary to another
+// word of dictionary: the rank of the value in the list is encrypted with
+// cycle walking (see subtle.EncryptRange) and the word with the resulting rank
+// is returned. Values that are not in the dictionary are handled according to
+// miss.
+//
+// The case of the value is kept: a lowercase, uppercase or capitalized value
+// gives a token in the same case, and any other value must be spelled as in
+// the list and gives a token spelled as in the list. Ranks are cycle walked
+// until the token can carry the case, so Detokenize recovers the value exactly.
+func WithDictionary(dictionary *Dictionary, miss DictionaryMissPolicy) Option {
+	return func(c *config) error {
+		if dictionary == nil {
+			return fmt.Errorf("dictionary cannot be nil")
+		}
+		if miss < DictionaryMissError || miss > DictionaryMissPassThrough {
+			return fmt.Errorf("unsupported dictionary miss policy: %v", miss)
+		}
+		if err := c.setMode(modeDictionary); err != nil {
+			return err
+		}
+		c.dictionary = dictionary
+		c.dictionaryMiss = miss
+		return nil
+	}
+}
+
+// wordCase is the letter case of a word.
+type wordCase int
+
+const (
+	caseLower wordCase = iota
+	caseUpper
+	caseTitle
+	caseAsListed
+)
+
+// caseOf returns the case of s. Words without letters are caseAsListed.
+func caseOf(s string) wordCase {
+	lower, upper := strings.ToLower(s), strings.ToUpper(s)
+	switch {
+	case lower == upper:
+		return caseAsListed
+	case s == lower:
+		return caseLower
+	case s == upper:
+		return caseUpper
+	case s == titleCase(s):
+		return caseTitle
+	default:
+		return caseAsListed
+	}
+}
+
+// withCase returns word in case wc.
+func withCase(word string, wc wordCase) string {
+	switch wc {
+	case caseLower:
+		return strings.ToLower(word)
+	case caseUpper:
+		return strings.ToUpper(word)
+	case caseTitle:
+		return titleCase(word)
+	default:
+		return word
+	}
+}
+
+// titleCase returns s with its first rune in uppercase and the rest in lowercase.
+func titleCase(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return strings.ToUpper(string(r)) + strings.ToLower(s[size:])
+}
+
+// transformDictionary tokenizes (encrypt) or detokenizes s under WithDictionary.
+func (t *Tokenizer) transformDictionary(s string, encrypt bool) (string, error) {
+	d := t.config.dictionary
+	rank, ok := d.Rank(s)
+	if !ok {
+		switch t.config.dictionaryMiss {
+		case DictionaryMissPassThrough:
+			return s, nil
+		case DictionaryMissFallback:
+			return t.transformDictionaryMiss(s, encrypt)
+		default:
+			return "", fmt.Errorf("value %q is not in the dictionary", s)
+		}
+	}
+
+	wc := caseOf(s)
+	if withCase(d.Word(rank), wc) != s {
+		return "", fmt.Errorf("value %q must be lowercase, uppercase, capitalized or spelled as in the dictionary (%q)", s, d.Word(rank))
+	}
+
+	// Walk until the word can carry the case of s, so that Detokenize reads
+	// the same case from the token. The rank of s itself qualifies, so this
+	// is a permutation of the qualifying ranks.
+	c := bindCipher(t.cipher.(subtle.TweakableCipher), []byte("dictionary"))
+	x, last := big.NewInt(int64(rank)), big.NewInt(int64(d.Len()-1))
+	for step := 0; step < d.Len(); step++ {
+		var err error
+		if encrypt {
+			x, err = subtle.EncryptRange(c, x, big.NewInt(0), last)
+		} else {
+			x, err = subtle.DecryptRange(c, x, big.NewInt(0), last)
+		}
+		if err != nil {
+			return "", err
+		}
+		word := withCase(d.Word(int(x.Int64())), wc)
+		if caseOf(word) == wc {
+			return word, nil
+		}
+	}
+	return "", fmt.Errorf("no dictionary word can carry the case of %q", s)
+}
+
+// transformDictionaryMiss tokenizes (encrypt) or detokenizes s, which is not
+// in the dictionary, with class preservation, walking until the result is not
+// in the dictionary either.
+func (t *Tokenizer) transformDictionaryMiss(s string, encrypt bool) (string, error) {
+	c := bindCipher(t.cipher.(subtle.TweakableCipher), []byte("dictionary-fallback"))
+	for {
+		var err error
+		s, err = cipherASCII(c, s, encrypt)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := t.config.dictionary.Rank(s); !ok {
+			return s, nil
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package fpe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testNames is a small dictionary with words of every case.
+var testNames = []string{
+	"Alice", "Bob", "Carol", "Dave", "Eve", "Frank", "Grace", "Heidi", "Ivan", "Judy",
+	"Mallory", "Niaj", "Olivia", "Peggy", "Rupert", "Sybil", "Trent", "Victor", "Walter", "Zoe",
+	"McDonald", "DeVries", "O'Brien", "José", "X",
+}
+
+// TestDictionaryTokenization verifies that dictionary words map to dictionary
+// words in the same case and round-trip.
+func TestDictionaryTokenization(t *testing.T) {
+	dictionary, err := NewDictionary(testNames)
+	if err != nil {
+		t.Fatalf("NewDictionary failed: %v", err)
+	}
+	tokenizer, err := NewFF1Tokenizer(testKey, []byte("names"), WithDictionary(dictionary, DictionaryMissError))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	var values []string
+	for _, name := range testNames {
+		values = append(values, name, strings.ToLower(name))
+		if upper := strings.ToUpper(name); upper != name {
+			values = append(values, upper)
+		}
+	}
+
+	seen := make(map[string]string)
+	for _, value := range values {
+		tokenized, err := tokenizer.Tokenize(value)
+		if err != nil {
+			t.Fatalf("Tokenize(%s) failed: %v", value, err)
+		}
+		if _, ok := dictionary.Rank(tokenized); !ok {
+			t.Errorf("Token %s of %s is not in the dictionary", tokenized, value)
+		}
+		if caseOf(tokenized) != caseOf(value) {
+			t.Errorf("Case not preserved: %s -> %s", value, tokenized)
+		}
+		if other, ok := seen[tokenized]; ok {
+			t.Errorf("Collision: %s and %s both tokenize to %s", other, value, tokenized)
+		}
+		seen[tokenized] = value
+
+		detokenized, err := tokenizer.Detokenize(tokenized)
+		if err != nil {
+			t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+		}
+		if detokenized != value {
+			t.Errorf("Round-trip failed: %s -> %s -> %s", value, tokenized, detokenized)
+		}
+	}
+}
+
+// TestDictionaryMissPolicies verifies the handling of values that are not in
+// the dictionary.
+func TestDictionaryMissPolicies(t *testing.T) {
+	dictionary, err := NewDictionary(testNames)
+	if err != nil {
+		t.Fatalf("NewDictionary failed: %v", err)
+	}
+	newTokenizer := func(miss DictionaryMissPolicy) *Tokenizer {
+		tokenizer, err := NewFF1Tokenizer(testKey, nil, WithDictionary(dictionary, miss))
+		if err != nil {
+			t.Fatalf("NewFF1Tokenizer failed: %v", err)
+		}
+		return tokenizer
+	}
+
+	if _, err := newTokenizer(DictionaryMissError).Tokenize("Quentin"); err == nil {
+		t.Error("Expected an error for a value that is not in the dictionary")
+	}
+	if _, err := newTokenizer(DictionaryMissError).Tokenize("aLiCe"); err == nil {
+		t.Error("Expected an error for a value in an unsupported case")
+	}
+
+	passThrough := newTokenizer(DictionaryMissPassThrough)
+	if got, err := passThrough.Tokenize("Quentin"); err != nil || got != "Quentin" {
+		t.Errorf("Tokenize(Quentin) = %q, %v; want it unchanged", got, err)
+	}
+
+	fallback := newTokenizer(DictionaryMissFallback)
+	for _, value := range []string{"Quentin", "Bartholomew", "Anne-Marie"} {
+		tokenized, err := fallback.Tokenize(value)
+		if err != nil {
+			t.Fatalf("Tokenize(%s) failed: %v", value, err)
+		}
+		if _, ok := dictionary.Rank(tokenized); ok || tokenized == value || len(tokenized) != len(value) {
+			t.Errorf("Bad fallback token for %s: %s", value, tokenized)
+		}
+		detokenized, err := fallback.Detokenize(tokenized)
+		if err != nil {
+			t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+		}
+		if detokenized != value {
+			t.Errorf("Round-trip failed: %s -> %s -> %s", value, tokenized, detokenized)
+		}
+	}
+}
+
+// TestLoadDictionary verifies loading word lists from an fs.FS and a file.
+func TestLoadDictionary(t *testing.T) {
+	contents := "# First names\nAlice\n\n  Bob  \nCarol\n"
+
+	fsys := fstest.MapFS{"names.txt": &fstest.MapFile{Data: []byte(contents)}}
+	dictionary, err := LoadDictionary(fsys, "names.txt")
+	if err != nil {
+		t.Fatalf("LoadDictionary failed: %v", err)
+	}
+	if dictionary.Len() != 3 || dictionary.Word(1) != "Bob" {
+		t.Errorf("Unexpected dictionary: %d words, second %q", dictionary.Len(), dictionary.Word(1))
+	}
+
+	path := filepath.Join(t.TempDir(), "names.txt")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	dictionary, err = LoadDictionaryFile(path)
+	if err != nil {
+		t.Fatalf("LoadDictionaryFile failed: %v", err)
+	}
+	if rank, ok := dictionary.Rank("CAROL"); !ok || rank != 2 {
+		t.Errorf("Rank(CAROL) = %d, %v; want 2, true", rank, ok)
+	}
+
+	if _, err := LoadDictionary(fsys, "missing.txt"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	for _, words := range [][]string{nil, {"Alice"}, {"Alice", "ALICE"}, {"Alice", ""}} {
+		if _, err := NewDictionary(words); err == nil {
+			t.Errorf("Expected NewDictionary(%q) to fail", words)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package fpe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// EmailDomainPolicy selects what WithEmail does with the domain of an address.
+type EmailDomainPolicy int
+
+const (
+	// EmailKeepDomain leaves the domain in the clear, so tokens can still be
+	// grouped by domain. This is the default.
+	EmailKeepDomain EmailDomainPolicy = iota
+
+	// EmailTokenizeDomain also tokenizes the domain, except for its top-level
+	// label: "mail.example.com" becomes something like "qsrp.hwtbfzq.com". The
+	// same domain always tokenizes to the same token domain.
+	EmailTokenizeDomain
+)
+
+// String returns the name of the policy.
+func (p EmailDomainPolicy) String() string {
+	switch p {
+	case EmailKeepDomain:
+		return "KeepDomain"
+	case EmailTokenizeDomain:
+		return "TokenizeDomain"
+	default:
+		return fmt.Sprintf("EmailDomainPolicy(%d)", int(p))
+	}
+}
+
+const (
+	// maxEmailLength, maxLocalPartLength and maxLabelLength are the limits of RFC 5321.
+	maxEmailLength     = 254
+	maxLocalPartLength = 64
+	maxLabelLength     = 63
+
+	// emailSpecials are the characters besides letters and digits allowed in
+	// an RFC 5322 dot-atom (atext).
+	emailSpecials = "!#$%&'*+-/=?^_`{|}~"
+)
+
+// WithEmail makes the Tokenizer treat every value as an email address of the
+// form local-part@domain (RFC 5322 dot-atom syntax; quoted local parts and
+// address literals are not supported). Tokens are syntactically valid
+// addresses:
+//
+//   - In the local part only letters and digits are tokenized, each keeping its
+//     class (digit, uppercase, lowercase). Dots and other symbols stay in place,
+//     and a plus-addressing tag ("+news" in "jane.doe+news") is kept as-is.
+//   - The domain is kept or tokenized according to domain. A tokenized domain
+//     keeps its hyphens, dots and top-level label.
+//
+// The local part is bound to the domain through the tweak, so the same local
+// part tokenizes differently under different domains. Each tokenized part must
+// still meet the cipher's minimum domain size: with FF1, at least three letters
+// or digits in the local part (ignoring the tag).
+func WithEmail(domain EmailDomainPolicy) Option {
+	return func(c *config) error {
+		if domain != EmailKeepDomain && domain != EmailTokenizeDomain {
+			return fmt.Errorf("unsupported email domain policy: %v", domain)
+		}
+		if err := c.setMode(modeEmail); err != nil {
+			return err
+		}
+		c.emailDomain = domain
+		return nil
+	}
+}
+
+// transformEmail tokenizes (encrypt) or detokenizes s under WithEmail.
+func (t *Tokenizer) transformEmail(s string, encrypt bool) (string, error) {
+	local, domain, err := splitEmail(s)
+	if err != nil {
+		return "", err
+	}
+	c := t.cipher.(subtle.TweakableCipher)
+
+	plainDomain := domain
+	if t.config.emailDomain == EmailTokenizeDomain {
+		dot := strings.LastIndexByte(domain, '.')
+		if dot < 0 {
+			return "", fmt.Errorf("domain %q has no top-level label to preserve", domain)
+		}
+		head, err := cipherASCII(bindCipher(c, []byte("email-domain")), domain[:dot], encrypt)
+		if err != nil {
+			return "", fmt.Errorf("domain: %w", err)
+		}
+		domain = head + domain[dot:]
+		if !encrypt {
+			plainDomain = domain
+		}
+	}
+
+	base, tag := local, ""
+	if i := strings.IndexByte(local, '+'); i >= 0 {
+		base, tag = local[:i], local[i:]
+	}
+	// Domains are case-insensitive, so bind to the lowercase form
+	base, err = cipherASCII(bindCipher(c, []byte("email-local"), []byte(strings.ToLower(plainDomain))), base, encrypt)
+	if err != nil {
+		return "", fmt.Errorf("local part: %w", err)
+	}
+
+	return base + tag + "@" + domain, nil
+}
+
+// splitEmail validates s as local-part@domain and returns both parts.
+func splitEmail(s string) (local, domain string, err error) {
+	if len(s) > maxEmailLength {
+		return "", "", fmt.Errorf("email address is %d characters long (maximum %d)", len(s), maxEmailLength)
+	}
+	at := strings.LastIndexByte(s, '@')
+	if at < 0 {
+		return "", "", fmt.Errorf("email address %q has no '@'", s)
+	}
+	local, domain = s[:at], s[at+1:]
+
+	if local == "" || len(local) > maxLocalPartLength {
+		return "", "", fmt.Errorf("local part of %q must be 1 to %d characters", s, maxLocalPartLength)
+	}
+	for _, atom := range strings.Split(local, ".") {
+		if atom == "" {
+			return "", "", fmt.Errorf("local part of %q has an empty dot-separated atom", s)
+		}
+		for _, r := range atom {
+			if !isASCIIAlphanumeric(r) && !strings.ContainsRune(emailSpecials, r) {
+				return "", "", fmt.Errorf("local part of %q contains invalid character %q", s, r)
+			}
+		}
+	}
+
+	if domain == "" {
+		return "", "", fmt.Errorf("email address %q has an empty domain", s)
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > maxLabelLength {
+			return "", "", fmt.Errorf("domain of %q has a label that is empty or longer than %d characters", s, maxLabelLength)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return "", "", fmt.Errorf("domain label %q cannot start or end with a hyphen", label)
+		}
+		for _, r := range label {
+			if !isASCIIAlphanumeric(r) && r != '-' {
+				return "", "", fmt.Errorf("domain label %q contains invalid character %q", label, r)
+			}
+		}
+	}
+
+	return local, domain, nil
+}
+
+// cipherASCII encrypts (or decrypts) the ASCII letters and digits of s, each
+// within its class, leaving all other characters in place.
+func cipherASCII(c subtle.Cipher, s string, encrypt bool) (string, error) {
+	out := []rune(s)
+	var positions []int
+	var classes []*Alphabet
+	var data []rune
+	for i, r := range out {
+		if class := asciiClass(r); class != nil {
+			positions = append(positions, i)
+			classes = append(classes, class)
+			data = append(data, r)
+		}
+	}
+
+	data, err := cipherClasses(c, classes, data, encrypt)
+	if err != nil {
+		return "", err
+	}
+	for i, p := range positions {
+		out[p] = data[i]
+	}
+	return string(out), nil
+}
+
+// asciiClass returns the class of WithClassPreservation that contains r, or nil.
+func asciiClass(r rune) *Alphabet {
+	switch {
+	case r >= '0' && r <= '9':
+		return AlphabetNumeric
+	case r >= 'A' && r <= 'Z':
+		return AlphabetUppercase
+	case r >= 'a' && r <= 'z':
+		return AlphabetLowercase
+	default:
+		return nil
+	}
+}
+
+// isASCIIAlphanumeric reports whether r is an ASCII letter or digit.
+func isASCIIAlphanumeric(r rune) bool {
+	return asciiClass(r) != nil
+}
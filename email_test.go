@@ -0,0 +1,130 @@
+package fpe
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEmailTokenization verifies that tokens are valid addresses that keep
+// dots, symbols, tags and (optionally) the domain, and that they round-trip.
+func TestEmailTokenization(t *testing.T) {
+	addresses := []string{
+		"user@domain.com",
+		"Jane.Doe+newsletter@Example.org",
+		"first_last-99@mail.my-company.co.uk",
+		"o'brien@x-ray.io",
+	}
+
+	for _, policy := range []EmailDomainPolicy{EmailKeepDomain, EmailTokenizeDomain} {
+		t.Run(policy.String(), func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("email"), WithEmail(policy))
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+
+			for _, address := range addresses {
+				tokenized, err := tokenizer.Tokenize(address)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", address, err)
+				}
+				if _, _, err := splitEmail(tokenized); err != nil {
+					t.Errorf("Token %s is not a valid address: %v", tokenized, err)
+				}
+				if len(tokenized) != len(address) {
+					t.Errorf("Length not preserved: %s -> %s", address, tokenized)
+				}
+
+				local, domain, _ := splitEmail(address)
+				tokenLocal, tokenDomain, _ := splitEmail(tokenized)
+				if tokenLocal == local {
+					t.Errorf("Local part was not tokenized: %s", tokenized)
+				}
+				if i := strings.IndexByte(local, '+'); i >= 0 && !strings.HasSuffix(tokenLocal, local[i:]) {
+					t.Errorf("Plus tag not preserved: %s -> %s", address, tokenized)
+				}
+				for i := range local {
+					if asciiClass(rune(local[i])) == nil && local[i] != tokenLocal[i] {
+						t.Errorf("Symbol moved: %s -> %s", address, tokenized)
+					}
+				}
+
+				tld := domain[strings.LastIndexByte(domain, '.'):]
+				switch policy {
+				case EmailKeepDomain:
+					if tokenDomain != domain {
+						t.Errorf("Domain not kept: %s -> %s", address, tokenized)
+					}
+				case EmailTokenizeDomain:
+					if tokenDomain == domain || !strings.HasSuffix(tokenDomain, tld) {
+						t.Errorf("Domain not tokenized with its TLD kept: %s -> %s", address, tokenized)
+					}
+				}
+
+				detokenized, err := tokenizer.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != address {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", address, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestEmailDomainBinding verifies that tokenized domains are consistent across
+// addresses and that the local part is bound to the domain.
+func TestEmailDomainBinding(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithEmail(EmailTokenizeDomain))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	a, _ := tokenizer.Tokenize("alice@example.com")
+	b, _ := tokenizer.Tokenize("bob@example.com")
+	c, _ := tokenizer.Tokenize("alice@example.net")
+	if a[strings.IndexByte(a, '@'):] != b[strings.IndexByte(b, '@'):] {
+		t.Errorf("Same domain tokenized differently: %s, %s", a, b)
+	}
+	if a[:strings.IndexByte(a, '@')] == c[:strings.IndexByte(c, '@')] {
+		t.Errorf("Local part not bound to the domain: %s, %s", a, c)
+	}
+}
+
+// TestEmailValidation verifies that malformed addresses are rejected.
+func TestEmailValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithEmail(EmailKeepDomain))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for _, address := range []string{
+		"no-at-sign",
+		"@domain.com",
+		"user@",
+		"user..name@domain.com",
+		".user@domain.com",
+		"user name@domain.com",
+		"user@-domain.com",
+		"user@domain..com",
+		"user@exa_mple.com",
+		"\"quoted\"@domain.com",
+		"ab@domain.com", // too few characters for FF1
+	} {
+		if _, err := tokenizer.Tokenize(address); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", address)
+		}
+	}
+
+	domainTokenizer, err := NewFF1Tokenizer(testKey, nil, WithEmail(EmailTokenizeDomain))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := domainTokenizer.Tokenize("user@localhost"); err == nil {
+		t.Error("Expected an error for a domain without a top-level label")
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithEmail(EmailKeepDomain), WithRevealSuffix(2)); err == nil {
+		t.Error("Expected WithEmail and reveal options to be exclusive")
+	}
+}
@@ -0,0 +1,167 @@
+package fpe
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// Encoding is a binary-to-text encoding that WithEncoding tokenizes within.
+type Encoding int
+
+const (
+	// EncodingHex is hexadecimal, in lowercase or uppercase.
+	EncodingHex Encoding = iota
+
+	// EncodingBase32 is the standard base32 encoding of RFC 4648.
+	EncodingBase32
+
+	// EncodingBase32Hex is the "extended hex" base32 encoding of RFC 4648.
+	EncodingBase32Hex
+
+	// EncodingBase64 is the standard base64 encoding of RFC 4648.
+	EncodingBase64
+
+	// EncodingBase64URL is the URL- and filename-safe base64 encoding of RFC 4648.
+	EncodingBase64URL
+)
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingHex:
+		return "Hex"
+	case EncodingBase32:
+		return "Base32"
+	case EncodingBase32Hex:
+		return "Base32Hex"
+	case EncodingBase64:
+		return "Base64"
+	case EncodingBase64URL:
+		return "Base64URL"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+}
+
+// WithEncoding makes the Tokenizer treat every value as text in encoding, such
+// as a hex hash or a base64 API key, and return tokens that are valid in the
+// same encoding and have the same length:
+//
+//   - Hex values are tokenized digit by digit over radix 16, so values of any
+//     length of at least 3 digits work. The letter case of the value is kept;
+//     values with both upper and lowercase letters are rejected. Uppercase
+//     values are cycle walked to tokens that contain a letter, so that
+//     Detokenize can read the case back from the token.
+//   - Base32 and base64 values are decoded, the bytes are encrypted over radix
+//     256 (see subtle.EncryptBytes) and the result is encoded again. Padding is
+//     kept: padded values give padded tokens and unpadded values unpadded
+//     tokens. Values must be canonical (no line breaks, and unused bits of the
+//     last character zero) and decode to at least 2 bytes (3 for FF3-1).
+func WithEncoding(encoding Encoding) Option {
+	return func(c *config) error {
+		if encoding < EncodingHex || encoding > EncodingBase64URL {
+			return fmt.Errorf("unsupported encoding: %v", encoding)
+		}
+		if err := c.setMode(modeEncoding); err != nil {
+			return err
+		}
+		c.encoding = encoding
+		return nil
+	}
+}
+
+// transformEncoded tokenizes (encrypt) or detokenizes s under WithEncoding.
+func (t *Tokenizer) transformEncoded(s string, encrypt bool) (string, error) {
+	if t.config.encoding == EncodingHex {
+		return t.transformHex(s, encrypt)
+	}
+
+	codec := byteCodec(t.config.encoding, strings.HasSuffix(s, "="))
+	data, err := codec.decode(s)
+	if err != nil {
+		return "", fmt.Errorf("value %q is not valid %v: %w", s, t.config.encoding, err)
+	}
+	if codec.encode(data) != s {
+		return "", fmt.Errorf("value %q is not canonical %v", s, t.config.encoding)
+	}
+
+	if encrypt {
+		data, err = subtle.EncryptBytes(t.cipher, data)
+	} else {
+		data, err = subtle.DecryptBytes(t.cipher, data)
+	}
+	if err != nil {
+		return "", err
+	}
+	return codec.encode(data), nil
+}
+
+// transformHex tokenizes (encrypt) or detokenizes the hex value s.
+func (t *Tokenizer) transformHex(s string, encrypt bool) (string, error) {
+	upper := strings.ToLower(s) != s
+	if upper && strings.ToUpper(s) != s {
+		return "", fmt.Errorf("hex value %q mixes upper and lowercase letters", s)
+	}
+	numerals, err := AlphabetHex.Encode(strings.ToLower(s))
+	if err != nil {
+		return "", fmt.Errorf("value %q is not valid %v: %w", s, EncodingHex, err)
+	}
+
+	// Walk uppercase values until the result has a letter to carry the case.
+	// The value itself has one, so this is a permutation of such values.
+	for {
+		if encrypt {
+			numerals, err = t.cipher.Encrypt(numerals, AlphabetHex.String())
+		} else {
+			numerals, err = t.cipher.Decrypt(numerals, AlphabetHex.String())
+		}
+		if err != nil {
+			return "", err
+		}
+		out, err := AlphabetHex.Decode(numerals)
+		if err != nil {
+			return "", err
+		}
+		if !upper {
+			return out, nil
+		}
+		if hasHexLetter(out) {
+			return strings.ToUpper(out), nil
+		}
+	}
+}
+
+// codec decodes and encodes one of the byte encodings.
+type codec struct {
+	decode func(string) ([]byte, error)
+	encode func([]byte) string
+}
+
+// byteCodec returns the codec of encoding, with or without padding.
+func byteCodec(encoding Encoding, padded bool) codec {
+	switch encoding {
+	case EncodingBase32, EncodingBase32Hex:
+		enc := base32.StdEncoding
+		if encoding == EncodingBase32Hex {
+			enc = base32.HexEncoding
+		}
+		if !padded {
+			enc = enc.WithPadding(base32.NoPadding)
+		}
+		return codec{enc.DecodeString, enc.EncodeToString}
+	default:
+		enc := base64.StdEncoding
+		if encoding == EncodingBase64URL {
+			enc = base64.URLEncoding
+		}
+		if !padded {
+			enc = enc.WithPadding(base64.NoPadding)
+		}
+		enc = enc.Strict()
+		return codec{enc.DecodeString, enc.EncodeToString}
+	}
+}
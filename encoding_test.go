@@ -0,0 +1,166 @@
+package fpe
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestEncodingTokenization verifies that tokens decode in the same encoding,
+// keep the length and padding of the value, and round-trip.
+func TestEncodingTokenization(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding Encoding
+		value    string
+		decode   func(string) ([]byte, error)
+	}{
+		{"HexLower", EncodingHex, "9f86d081884c7d659a2feaa0c55ad015", hex.DecodeString},
+		{"HexUpper", EncodingHex, "9F86D081884C7D659A2FEAA0C55AD015", hex.DecodeString},
+		{"HexOddLength", EncodingHex, "abc12", nil},
+		{"Base32", EncodingBase32, "MFRGGZDFMZTWQ2LK", base32.StdEncoding.DecodeString},
+		{"Base32Padded", EncodingBase32, "MFRGGZDFMY======", base32.StdEncoding.DecodeString},
+		{"Base32Unpadded", EncodingBase32, "MFRGGZDFMY", base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString},
+		{"Base32Hex", EncodingBase32Hex, "C5H66P35CO======", base32.HexEncoding.DecodeString},
+		{"Base64", EncodingBase64, "c2stbGl2ZS0xMjM0NTY3ODkw+w==", base64.StdEncoding.DecodeString},
+		{"Base64WholeGroups", EncodingBase64, "AQIDBAUG", base64.StdEncoding.DecodeString},
+		{"Base64OnePad", EncodingBase64, "AQIDBA==", base64.StdEncoding.DecodeString},
+		{"Base64URL", EncodingBase64URL, "c2stbGl2ZS0xMjM0NTY3ODkw-_8", base64.RawURLEncoding.DecodeString},
+		{"Base64URLPadded", EncodingBase64URL, "c2stbGl2ZS0xMjM0NTY3ODkw-_8=", base64.URLEncoding.DecodeString},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("encoding"), WithEncoding(tc.encoding))
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+			tokenized, err := tokenizer.Tokenize(tc.value)
+			if err != nil {
+				t.Fatalf("Tokenize(%s) failed: %v", tc.value, err)
+			}
+			if tokenized == tc.value || len(tokenized) != len(tc.value) {
+				t.Errorf("Bad token for %s: %s", tc.value, tokenized)
+			}
+			if tc.decode != nil {
+				if _, err := tc.decode(tokenized); err != nil {
+					t.Errorf("Token %s is not valid %v: %v", tokenized, tc.encoding, err)
+				}
+			}
+			if strings.Count(tokenized, "=") != strings.Count(tc.value, "=") {
+				t.Errorf("Padding not preserved: %s -> %s", tc.value, tokenized)
+			}
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+			}
+			if detokenized != tc.value {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", tc.value, tokenized, detokenized)
+			}
+		})
+	}
+}
+
+// TestEncodingTokenizationFF31 verifies that FF3-1 tokens stay valid base64.
+func TestEncodingTokenizationFF31(t *testing.T) {
+	tokenizer, err := NewFF31Tokenizer(testKey, []byte("tweak77"), WithEncoding(EncodingBase64URL))
+	if err != nil {
+		t.Fatalf("NewFF31Tokenizer failed: %v", err)
+	}
+	value := "3q2-7wABAgMEBQYHCAkKCw"
+	tokenized, err := tokenizer.Tokenize(value)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if _, err := base64.RawURLEncoding.Strict().DecodeString(tokenized); err != nil {
+		t.Errorf("Token %s is not valid base64url: %v", tokenized, err)
+	}
+	detokenized, err := tokenizer.Detokenize(tokenized)
+	if err != nil {
+		t.Fatalf("Detokenize failed: %v", err)
+	}
+	if detokenized != value {
+		t.Errorf("Round-trip failed: %s -> %s -> %s", value, tokenized, detokenized)
+	}
+}
+
+// TestEncodingTokenizationHexUpperCase verifies that an uppercase hex value
+// whose token would have no letters is walked to a token that keeps its case.
+func TestEncodingTokenizationHexUpperCase(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithEncoding(EncodingHex))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	for _, letterless := range []string{"091", "555", "1234", "000000"} {
+		// The lowercase value of a token without letters
+		value, err := tokenizer.Detokenize(letterless)
+		if err != nil {
+			t.Fatalf("Detokenize(%s) failed: %v", letterless, err)
+		}
+		upper := strings.ToUpper(value)
+		if upper == value {
+			continue
+		}
+		tokenized, err := tokenizer.Tokenize(upper)
+		if err != nil {
+			t.Fatalf("Tokenize(%s) failed: %v", upper, err)
+		}
+		if !hasHexLetter(tokenized) || strings.ToUpper(tokenized) != tokenized {
+			t.Errorf("Expected an uppercase token with a letter for %s, got %s", upper, tokenized)
+		}
+		detokenized, err := tokenizer.Detokenize(tokenized)
+		if err != nil {
+			t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+		}
+		if detokenized != upper {
+			t.Errorf("Round-trip failed: %s -> %s -> %s", upper, tokenized, detokenized)
+		}
+	}
+}
+
+// TestEncodingTokenizationErrors verifies that invalid, non-canonical and too
+// short values and conflicting options are rejected.
+func TestEncodingTokenizationErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding Encoding
+		value    string
+	}{
+		{"HexMixedCase", EncodingHex, "9f86D081"},
+		{"HexInvalid", EncodingHex, "9f86g081"},
+		{"HexTooShort", EncodingHex, "9f"},
+		{"Base64Invalid", EncodingBase64, "c2st*Gl2"},
+		{"Base64URLAlphabet", EncodingBase64URL, "c2st+Gl2"},
+		{"Base64NonCanonical", EncodingBase64, "AQJ="},
+		{"Base64LineBreak", EncodingBase64, "AQID\nBAUG"},
+		{"Base64OneByte", EncodingBase64, "AQ=="},
+		{"Base32Lowercase", EncodingBase32, "mfrggzdf"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, nil, WithEncoding(tc.encoding))
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+			if token, err := tokenizer.Tokenize(tc.value); err == nil {
+				t.Errorf("Expected an error for %q, got %q", tc.value, token)
+			}
+		})
+	}
+
+	for _, opts := range [][]Option{
+		{WithEncoding(Encoding(99))},
+		{WithEncoding(EncodingHex), WithAlphabet(AlphabetHex)},
+		{WithEncoding(EncodingBase64), WithRevealPrefix(2)},
+	} {
+		if _, err := NewFF1Tokenizer(testKey, nil, opts...); err == nil {
+			t.Errorf("Expected an error for options %d", len(opts))
+		}
+	}
+	_, err := NewFF1Tokenizer(testKey, nil, WithEncoding(EncodingHex), WithAlphabet(AlphabetHex))
+	if want := "only one of WithEncoding and WithAlphabet can be used"; err == nil || err.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, err)
+	}
+}
@@ -1,20 +1,22 @@
 package fpe
 
 // SeparateFormatAndData separates format characters (hyphens, dots, etc.) from data characters.
-// Returns a format mask (true = format char, false = data char) and the data characters only.
+// Returns a format mask (true = format char, false = data char) with one entry per character
+// (rune) of s, and the data characters only.
 // Format characters include: hyphens (-), dots (.), colons (:), at signs (@), etc.
 func SeparateFormatAndData(s string) ([]bool, string) {
-	formatMask := make([]bool, len(s))
-	dataChars := make([]byte, 0, len(s))
+	runes := []rune(s)
+	formatMask := make([]bool, len(runes))
+	dataChars := make([]rune, 0, len(runes))
 
-	for i, char := range s {
+	for i, char := range runes {
 		// Check if it's a format character (non-alphanumeric)
 		// Format characters include: hyphens (-), dots (.), colons (:), at signs (@)
 		if (char >= '0' && char <= '9') ||
 			(char >= 'A' && char <= 'Z') ||
 			(char >= 'a' && char <= 'z') {
 			formatMask[i] = false
-			dataChars = append(dataChars, byte(char))
+			dataChars = append(dataChars, char)
 		} else {
 			// Format character: preserve position
 			formatMask[i] = true
@@ -25,18 +27,21 @@ func SeparateFormatAndData(s string) ([]bool, string) {
 }
 
 // ReconstructWithFormat reconstructs a string with format characters in their original positions.
+// formatMask has one entry per character (rune) of original.
 func ReconstructWithFormat(data string, formatMask []bool, original string) string {
-	result := make([]byte, len(formatMask))
+	originalRunes := []rune(original)
+	dataRunes := []rune(data)
+	result := make([]rune, len(formatMask))
 	dataIdx := 0
 
 	for i := 0; i < len(formatMask); i++ {
 		if formatMask[i] {
 			// Preserve format character from original
-			result[i] = original[i]
+			result[i] = originalRunes[i]
 		} else {
 			// Use data character
-			if dataIdx < len(data) {
-				result[i] = data[dataIdx]
+			if dataIdx < len(dataRunes) {
+				result[i] = dataRunes[dataIdx]
 				dataIdx++
 			} else {
 				// Fallback if data is shorter than expected
@@ -1,5 +1,6 @@
 // Package fpe implements Format-Preserving Encryption (FPE) using the FF1 algorithm.
 // FF1 is a NIST-standardized format-preserving encryption algorithm (NIST SP 800-38G).
+// FF3-1 (NIST SP 800-38G Rev. 1) is also available via NewFF31.
 //
 // This package provides a clean, provider-agnostic implementation of FF1 that can
 // be used with any key management system. It preserves the format of input data
@@ -13,7 +14,7 @@
 //
 // Example usage:
 //
-//	key := []byte("your-encryption-key-32-bytes-long!")
+//	key := []byte("an-example-aes256-key-32-bytes!!")
 //	tweak := []byte("tenant-1234|customer.ssn")
 //
 //	fpe, err := fpe.NewFF1(key, tweak)
@@ -34,10 +35,18 @@
 //		log.Fatal(err)
 //	}
 //	// plaintext will be "123-45-6789"
+//
+// To detokenize without the original plaintext, fix the alphabet up front and
+// use the v2 interface (FPEv2):
+//
+//	tokenizer, err := fpe.NewFF1Tokenizer(key, tweak, fpe.WithAlphabet(fpe.AlphabetNumeric))
+//	tokenized, err := tokenizer.Tokenize("123-45-6789")
+//	plaintext, err := tokenizer.Detokenize(tokenized)
 package fpe
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/vdparikh/fpe/subtle"
 )
@@ -46,21 +55,44 @@ import (
 // FF1 is based on a Feistel network and preserves the format of input data.
 // This is a high-level wrapper around the subtle.FF1 implementation.
 type FF1 struct {
-	ff1 *subtle.FF1
+	ff1       *subtle.FF1
+	tokenizer *Tokenizer
 }
 
 // NewFF1 creates a new FF1 FPE instance with the given key and tweak.
-// The key should be at least 16 bytes (AES-128) or 32 bytes (AES-256).
+// The key must be 16, 24, or 32 bytes (AES-128, AES-192, or AES-256).
 // The tweak is a public, non-secret value that ensures different ciphertexts
 // for the same plaintext when the tweak changes.
 //
+// Options fix the format at construction (see WithAlphabet,
+// WithClassPreservation and WithFormat). When options are given, Detokenize
+// inverts Tokenize from the token alone and ignores its originalPlaintext and
+// alphabet arguments.
+//
 // This function creates a high-level wrapper around the subtle.FF1 implementation.
 // For Tink integration, use tinkfpe.New() instead.
-func NewFF1(key, tweak []byte) (*FF1, error) {
+func NewFF1(key, tweak []byte, opts ...Option) (*FF1, error) {
 	ff1, err := subtle.NewFF1(key, tweak)
 	if err != nil {
 		return nil, err
 	}
+	f := &FF1{ff1: ff1}
+	if len(opts) > 0 {
+		if f.tokenizer, err = NewTokenizer(ff1, opts...); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// NewFF1Legacy creates an FF1 FPE instance that uses the pre-NIST round function
+// of earlier releases (see subtle.ModeLegacy). Tokens produced by earlier releases
+// can only be detokenized with this instance; new tokens should use NewFF1.
+func NewFF1Legacy(key, tweak []byte) (*FF1, error) {
+	ff1, err := subtle.NewFF1Legacy(key, tweak)
+	if err != nil {
+		return nil, err
+	}
 	return &FF1{ff1: ff1}, nil
 }
 
@@ -70,6 +102,81 @@ func NewFF1(key, tweak []byte) (*FF1, error) {
 //
 // Returns the tokenized (encrypted) value that maintains the same format as the input.
 func (f *FF1) Tokenize(plaintext string) (string, error) {
+	if f.tokenizer != nil {
+		return f.tokenizer.Tokenize(plaintext)
+	}
+	return tokenize(f.ff1, plaintext)
+}
+
+// Detokenize decrypts tokenized value using format-preserving encryption.
+// The alphabet parameter should match what was used during tokenization.
+// If empty, it will be determined from the tokenized data (may not match original).
+//
+// For best results, pass the alphabet determined from the original plaintext.
+// If the FF1 was created with options, both hints are ignored.
+func (f *FF1) Detokenize(tokenized string, originalPlaintext string, alphabet string) (string, error) {
+	if f.tokenizer != nil {
+		return f.tokenizer.Detokenize(tokenized)
+	}
+	return detokenize(f.ff1, tokenized, originalPlaintext, alphabet)
+}
+
+// TokenizeWithTweak is Tokenize with tweak in place of the tweak the FF1 was
+// created with, so that one FF1 can serve many tenants or columns. The tweak
+// can be at most subtle.FF1MaxTweakSize bytes.
+func (f *FF1) TokenizeWithTweak(plaintext string, tweak []byte) (string, error) {
+	if f.tokenizer != nil {
+		return f.tokenizer.TokenizeWithTweak(plaintext, tweak)
+	}
+	return tokenize(f.ff1.WithTweak(tweak), plaintext)
+}
+
+// DetokenizeWithTweak decrypts a value produced by TokenizeWithTweak with the
+// same tweak. The other arguments behave as in Detokenize.
+func (f *FF1) DetokenizeWithTweak(tokenized string, tweak []byte, originalPlaintext string, alphabet string) (string, error) {
+	if f.tokenizer != nil {
+		return f.tokenizer.DetokenizeWithTweak(tokenized, tweak)
+	}
+	return detokenize(f.ff1.WithTweak(tweak), tokenized, originalPlaintext, alphabet)
+}
+
+// EncryptRange encrypts x, an integer in [min, max] (both inclusive), to
+// another integer in the same range, for values such as an account number
+// below 4,000,000,000 or an age in 0..120. See subtle.EncryptRange.
+func (f *FF1) EncryptRange(x, min, max *big.Int) (*big.Int, error) {
+	return f.ff1.EncryptRange(x, min, max)
+}
+
+// DecryptRange inverts EncryptRange.
+func (f *FF1) DecryptRange(y, min, max *big.Int) (*big.Int, error) {
+	return f.ff1.DecryptRange(y, min, max)
+}
+
+// EncryptBytes encrypts data, an opaque byte string such as a 16-byte device
+// ID, to a byte string of the same length over radix 256. See
+// subtle.EncryptBytes.
+func (f *FF1) EncryptBytes(data []byte) ([]byte, error) {
+	return f.ff1.EncryptBytes(data)
+}
+
+// DecryptBytes inverts EncryptBytes.
+func (f *FF1) DecryptBytes(data []byte) ([]byte, error) {
+	return f.ff1.DecryptBytes(data)
+}
+
+// EncryptBits encrypts the first bitLen bits of data over radix 2, for values
+// that are not a whole number of bytes. See subtle.EncryptBits.
+func (f *FF1) EncryptBits(data []byte, bitLen int) ([]byte, error) {
+	return f.ff1.EncryptBits(data, bitLen)
+}
+
+// DecryptBits inverts EncryptBits.
+func (f *FF1) DecryptBits(data []byte, bitLen int) ([]byte, error) {
+	return f.ff1.DecryptBits(data, bitLen)
+}
+
+// tokenize implements Tokenize on top of any numeral-string cipher.
+func tokenize(c subtle.Cipher, plaintext string) (string, error) {
 	// Step 1: Separate format characters (hyphens, dots, etc.) from data characters
 	formatMask, dataChars := SeparateFormatAndData(plaintext)
 
@@ -82,8 +189,8 @@ func (f *FF1) Tokenize(plaintext string) (string, error) {
 	// Step 3: Convert data characters to numeric representation
 	dataNumeric := StringToNumeric(dataChars, alphabet)
 
-	// Step 4: Use FF1 algorithm for format-preserving encryption
-	tokenizedNumeric, err := f.ff1.Encrypt(dataNumeric, alphabet)
+	// Step 4: Use the cipher for format-preserving encryption
+	tokenizedNumeric, err := c.Encrypt(dataNumeric, alphabet)
 	if err != nil {
 		return "", fmt.Errorf("failed to tokenize: %w", err)
 	}
@@ -95,12 +202,8 @@ func (f *FF1) Tokenize(plaintext string) (string, error) {
 	return tokenized, nil
 }
 
-// Detokenize decrypts tokenized value using format-preserving encryption.
-// The alphabet parameter should match what was used during tokenization.
-// If empty, it will be determined from the tokenized data (may not match original).
-//
-// For best results, pass the alphabet determined from the original plaintext.
-func (f *FF1) Detokenize(tokenized string, originalPlaintext string, alphabet string) (string, error) {
+// detokenize implements Detokenize on top of any numeral-string cipher.
+func detokenize(c subtle.Cipher, tokenized string, originalPlaintext string, alphabet string) (string, error) {
 	// Step 1: Separate format characters from data characters
 	formatMask, dataChars := SeparateFormatAndData(tokenized)
 
@@ -120,8 +223,8 @@ func (f *FF1) Detokenize(tokenized string, originalPlaintext string, alphabet st
 	// Step 3: Convert tokenized data to numeric representation
 	tokenizedNumeric := StringToNumeric(dataChars, alphabet)
 
-	// Step 4: Use FF1 algorithm for format-preserving decryption
-	plaintextNumeric, err := f.ff1.Decrypt(tokenizedNumeric, alphabet)
+	// Step 4: Use the cipher for format-preserving decryption
+	plaintextNumeric, err := c.Decrypt(tokenizedNumeric, alphabet)
 	if err != nil {
 		return "", fmt.Errorf("failed to detokenize: %w", err)
 	}
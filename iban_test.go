@@ -0,0 +1,105 @@
+package fpe
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestIBANTokenization verifies that tokens are valid IBANs that keep the
+// country, spacing and BBAN structure, and that they round-trip.
+func TestIBANTokenization(t *testing.T) {
+	ibans := []string{
+		"DE89370400440532013000",
+		"DE89 3704 0044 0532 0130 00",
+		"GB82WEST12345698765432",
+		"FR1420041010050500013M02606",
+		"NL91ABNA0417164300",
+		"BE68539007547034",
+		"CH9300762011623852957",
+		"NO9386011117947",
+		"IT60X0542811101000000123456",
+		"BR1800360305000010009795493C1",
+		"MU17BOMM0101101030300200000MUR",
+	}
+
+	tokenizers := map[string]func() (*Tokenizer, error){
+		"FF1": func() (*Tokenizer, error) {
+			return NewFF1Tokenizer(testKey, []byte("iban"), WithIBAN())
+		},
+		"FF3-1": func() (*Tokenizer, error) {
+			return NewFF31Tokenizer(testKey, []byte("tweak77"), WithIBAN())
+		},
+	}
+	for name, newTokenizer := range tokenizers {
+		t.Run(name, func(t *testing.T) {
+			tokenizer, err := newTokenizer()
+			if err != nil {
+				t.Fatalf("Failed to create tokenizer: %v", err)
+			}
+
+			for _, iban := range ibans {
+				tokenized, err := tokenizer.Tokenize(iban)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", iban, err)
+				}
+				if tokenized == iban || len(tokenized) != len(iban) || tokenized[:2] != iban[:2] {
+					t.Errorf("Bad token for %s: %s", iban, tokenized)
+				}
+				for i := range iban {
+					if (iban[i] == ' ') != (tokenized[i] == ' ') {
+						t.Errorf("Spacing not preserved: %s -> %s", iban, tokenized)
+						break
+					}
+				}
+
+				compact := strings.ReplaceAll(tokenized, " ", "")
+				classes, _ := ibanClasses(compact[:2])
+				for i, r := range compact[4:] {
+					if !classes[i].Contains(r) {
+						t.Errorf("Token %s breaks the BBAN structure at %d", tokenized, i)
+					}
+				}
+				if r, _ := mod97(compact[4:] + compact[:4]); r != 1 {
+					t.Errorf("Token %s does not pass the mod-97 check", tokenized)
+				}
+
+				detokenized, err := tokenizer.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != iban {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", iban, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestIBANValidation verifies that malformed IBANs are rejected.
+func TestIBANValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithIBAN())
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for _, iban := range []string{
+		"",
+		"DE8",
+		"DE88370400440532013000", // wrong check digits
+		"DE8937040044053201300",  // too short
+		"XX89370400440532013000", // unknown country
+		"GB82WEST1234569876543X", // letter in a numeric position
+		"de89370400440532013000", // lowercase
+		"GB82west12345698765432", // lowercase
+	} {
+		if _, err := tokenizer.Tokenize(iban); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", iban)
+		}
+	}
+
+	for country := range ibanStructures {
+		if _, err := ibanClasses(country); err != nil {
+			t.Errorf("Invalid BBAN structure for %s: %v", country, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package fpe
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+// TestIPTokenization verifies that tokens are addresses of the same family,
+// that kept prefixes stay in the clear and that tokens round-trip.
+func TestIPTokenization(t *testing.T) {
+	addresses := []string{
+		"192.168.1.10",
+		"10.0.0.1",
+		"0.0.0.0",
+		"2001:db8::1",
+		"fe80::1%eth0",
+		"::ffff:192.0.2.1",
+	}
+
+	for _, mode := range []IPMode{IPFull, IPPrefixPreserving} {
+		t.Run(mode.String(), func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("ip"), WithIP(mode), WithIPKeepPrefix(8, 16))
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+
+			for _, address := range addresses {
+				tokenized, err := tokenizer.Tokenize(address)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", address, err)
+				}
+				addr, token := netip.MustParseAddr(address), netip.MustParseAddr(tokenized)
+				if token.Is4() != addr.Is4() || token.Zone() != addr.Zone() {
+					t.Errorf("Family or zone not preserved: %s -> %s", address, tokenized)
+				}
+				if token == addr {
+					t.Errorf("Address was not tokenized: %s", address)
+				}
+				keep := 8
+				if addr.Is6() {
+					keep = 16
+				}
+				if netip.PrefixFrom(addr, keep).Masked() != netip.PrefixFrom(token, keep).Masked() {
+					t.Errorf("First %d bits not kept: %s -> %s", keep, address, tokenized)
+				}
+
+				detokenized, err := tokenizer.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != addr.String() {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", address, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestIPFullKeepPrefix verifies that IPFull tokenizes the host part of an
+// IPv4 /24 and /16 within the subnet, with cycle walking where the host part
+// is below the cipher's minimum domain.
+func TestIPFullKeepPrefix(t *testing.T) {
+	tokenizers := map[string]func(keep int) (*Tokenizer, error){
+		"FF1": func(keep int) (*Tokenizer, error) {
+			return NewFF1Tokenizer(testKey, []byte("ip"), WithIP(IPFull), WithIPKeepPrefix(keep, 48))
+		},
+		"FF3-1": func(keep int) (*Tokenizer, error) {
+			return NewFF31Tokenizer(testKey, []byte("tweak77"), WithIP(IPFull), WithIPKeepPrefix(keep, 48))
+		},
+	}
+	for name, newTokenizer := range tokenizers {
+		for _, keep := range []int{24, 16} {
+			if name == "FF3-1" && keep == 24 {
+				continue // 8 bits are too few for FF3-1
+			}
+			t.Run(fmt.Sprintf("%s/%d", name, keep), func(t *testing.T) {
+				tokenizer, err := newTokenizer(keep)
+				if err != nil {
+					t.Fatalf("Failed to create tokenizer: %v", err)
+				}
+				seen := make(map[string]bool)
+				for i := 0; i < 64; i++ {
+					addr := netip.AddrFrom4([4]byte{192, 168, byte(i % 4), byte(i)})
+					tokenized, err := tokenizer.Tokenize(addr.String())
+					if err != nil {
+						t.Fatalf("Tokenize(%s) failed: %v", addr, err)
+					}
+					token := netip.MustParseAddr(tokenized)
+					if netip.PrefixFrom(addr, keep).Masked() != netip.PrefixFrom(token, keep).Masked() {
+						t.Errorf("First %d bits not kept: %s -> %s", keep, addr, tokenized)
+					}
+					if seen[tokenized] {
+						t.Errorf("Token %s is not unique", tokenized)
+					}
+					seen[tokenized] = true
+
+					detokenized, err := tokenizer.Detokenize(tokenized)
+					if err != nil {
+						t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+					}
+					if detokenized != addr.String() {
+						t.Errorf("Round-trip failed: %s -> %s -> %s", addr, tokenized, detokenized)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestIPPrefixPreserving verifies that tokens share exactly as long a prefix
+// as the addresses they came from, with both FF1 and FF3-1.
+func TestIPPrefixPreserving(t *testing.T) {
+	pairs := [][2]string{
+		{"192.168.1.10", "192.168.1.200"},
+		{"192.168.1.10", "192.168.2.10"},
+		{"10.1.2.3", "172.16.2.3"},
+		{"2001:db8:aaaa::1", "2001:db8:aaaa::2"},
+		{"2001:db8:aaaa::1", "2001:db8:bbbb::1"},
+	}
+
+	tokenizers := map[string]func() (*Tokenizer, error){
+		"FF1": func() (*Tokenizer, error) {
+			return NewFF1Tokenizer(testKey, nil, WithIP(IPPrefixPreserving))
+		},
+		"FF3-1": func() (*Tokenizer, error) {
+			return NewFF31Tokenizer(testKey, []byte("tweak77"), WithIP(IPPrefixPreserving))
+		},
+	}
+	for name, newTokenizer := range tokenizers {
+		t.Run(name, func(t *testing.T) {
+			tokenizer, err := newTokenizer()
+			if err != nil {
+				t.Fatalf("Failed to create tokenizer: %v", err)
+			}
+
+			for _, pair := range pairs {
+				a, b := netip.MustParseAddr(pair[0]), netip.MustParseAddr(pair[1])
+				ta, err := tokenizer.TokenizeAddr(a)
+				if err != nil {
+					t.Fatalf("TokenizeAddr(%s) failed: %v", a, err)
+				}
+				tb, err := tokenizer.TokenizeAddr(b)
+				if err != nil {
+					t.Fatalf("TokenizeAddr(%s) failed: %v", b, err)
+				}
+				if got, want := commonPrefixBits(ta, tb), commonPrefixBits(a, b); got != want {
+					t.Errorf("Tokens %s and %s share %d bits, addresses %s and %s share %d", ta, tb, got, a, b, want)
+				}
+
+				back, err := tokenizer.DetokenizeAddr(ta)
+				if err != nil {
+					t.Fatalf("DetokenizeAddr(%s) failed: %v", ta, err)
+				}
+				if back != a {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", a, ta, back)
+				}
+			}
+		})
+	}
+}
+
+// TestIPValidation verifies option and input validation.
+func TestIPValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithIP(IPFull))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	for _, address := range []string{"", "256.1.1.1", "1.2.3", "2001:db8::g", "example.com"} {
+		if _, err := tokenizer.Tokenize(address); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", address)
+		}
+	}
+	if _, err := tokenizer.TokenizeAddr(netip.Addr{}); err == nil {
+		t.Error("Expected an error for the zero netip.Addr")
+	}
+
+	// Keeping 24 bits of an IPv4 address leaves 8 bits, too few for FF3-1
+	narrow, err := NewFF31Tokenizer(testKey, []byte("tweak77"), WithIP(IPFull), WithIPKeepPrefix(24, 64))
+	if err != nil {
+		t.Fatalf("NewFF31Tokenizer failed: %v", err)
+	}
+	if _, err := narrow.Tokenize("192.168.1.10"); err == nil {
+		t.Error("Expected an error when too few bits are left to encrypt")
+	}
+	if _, err := narrow.Tokenize("2001:db8::1"); err != nil {
+		t.Errorf("Tokenize failed for IPv6: %v", err)
+	}
+
+	invalid := [][]Option{
+		{WithIP(IPMode(7))},
+		{WithIP(IPFull), WithIPKeepPrefix(33, 0)},
+		{WithIP(IPFull), WithIPKeepPrefix(0, -1)},
+		{WithIPKeepPrefix(8, 16)},
+		{WithIP(IPFull), WithRevealPrefix(2)},
+		{WithIP(IPFull), WithClassPreservation()},
+	}
+	for i, opts := range invalid {
+		if _, err := NewFF1Tokenizer(testKey, nil, opts...); err == nil {
+			t.Errorf("Expected option set %d to be rejected", i)
+		}
+	}
+
+	plain, err := NewFF1Tokenizer(testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := plain.TokenizeAddr(netip.MustParseAddr("10.0.0.1")); err == nil {
+		t.Error("Expected TokenizeAddr to require WithIP")
+	}
+}
+
+// commonPrefixBits returns the number of leading bits a and b share.
+func commonPrefixBits(a, b netip.Addr) int {
+	x, y := a.AsSlice(), b.AsSlice()
+	for i := range x {
+		for bit := 7; bit >= 0; bit-- {
+			if (x[i]>>bit)&1 != (y[i]>>bit)&1 {
+				return 8*i + 7 - bit
+			}
+		}
+	}
+	return 8 * len(x)
+}
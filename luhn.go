@@ -0,0 +1,80 @@
+package fpe
+
+import (
+	"fmt"
+)
+
+// LuhnPolicy selects how tokens relate to the Luhn (mod 10) check used by
+// payment card numbers. See WithLuhn.
+type LuhnPolicy int
+
+const (
+	// LuhnNone ignores the Luhn check. This is the default.
+	LuhnNone LuhnPolicy = iota
+
+	// LuhnValid produces tokens that pass the Luhn check, so they are accepted
+	// by card number validators.
+	LuhnValid
+
+	// LuhnInvalid produces tokens that always fail the Luhn check, so a token
+	// can never be mistaken for a real card number.
+	LuhnInvalid
+)
+
+// String returns the name of the policy.
+func (p LuhnPolicy) String() string {
+	switch p {
+	case LuhnNone:
+		return "None"
+	case LuhnValid:
+		return "Valid"
+	case LuhnInvalid:
+		return "Invalid"
+	default:
+		return fmt.Sprintf("LuhnPolicy(%d)", int(p))
+	}
+}
+
+// WithLuhn tokenizes Luhn-valid card numbers (PANs) according to policy.
+// Inputs must pass the Luhn check, and Detokenize recovers them exactly.
+//
+// The data characters must be digits: without WithAlphabet the alphabet is
+// AlphabetNumeric, and WithCharacterClasses and WithFormat cannot be combined
+// with it. It works with WithRevealPrefix and WithRevealSuffix; with LuhnValid
+// even the check digit may be revealed.
+//
+// LuhnValid cycle walks the hidden digits until the whole number passes the
+// Luhn check, which takes ten encryptions on average. LuhnInvalid does the same
+// and then adds one (mod 10) to the last hidden digit; changing any single digit
+// always breaks the Luhn check, and Detokenize subtracts it again.
+func WithLuhn(policy LuhnPolicy) Option {
+	return func(c *config) error {
+		if policy < LuhnNone || policy > LuhnInvalid {
+			return fmt.Errorf("unsupported Luhn policy: %v", policy)
+		}
+		c.luhn = policy
+		return nil
+	}
+}
+
+// luhnValid reports whether the digits of s at positions pass the Luhn check.
+func luhnValid(s []rune, positions []int) bool {
+	sum := 0
+	for i := range positions {
+		// Double every second digit counting from the rightmost one
+		d := int(s[positions[len(positions)-1-i]] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// shiftDigit adds delta (mod 10) to the digit at s[p].
+func shiftDigit(s []rune, p int, delta int) {
+	s[p] = '0' + rune((int(s[p]-'0')+delta+10)%10)
+}
@@ -0,0 +1,120 @@
+package fpe
+
+import (
+	"testing"
+)
+
+// TestLuhnValid verifies the Luhn check against known card numbers.
+func TestLuhnValid(t *testing.T) {
+	testCases := []struct {
+		pan   string
+		valid bool
+	}{
+		{"4111111111111111", true},
+		{"4532015112830366", true},
+		{"5555555555554444", true},
+		{"378282246310005", true},
+		{"4111111111111112", false},
+		{"4532015112830367", false},
+	}
+
+	for _, tc := range testCases {
+		runes := []rune(tc.pan)
+		positions := make([]int, len(runes))
+		for i := range positions {
+			positions[i] = i
+		}
+		if got := luhnValid(runes, positions); got != tc.valid {
+			t.Errorf("luhnValid(%s) = %v, want %v", tc.pan, got, tc.valid)
+		}
+	}
+}
+
+// TestLuhnTokenization verifies that LuhnValid tokens pass and LuhnInvalid
+// tokens fail the Luhn check, with and without revealed digits, and that both
+// recover the exact PAN.
+func TestLuhnTokenization(t *testing.T) {
+	pans := []string{
+		"4111-1111-1111-1111",
+		"4532015112830366",
+		"5555 5555 5555 4444",
+		"378282246310005",
+	}
+
+	testCases := []struct {
+		name   string
+		policy LuhnPolicy
+		opts   []Option
+	}{
+		{"Valid", LuhnValid, nil},
+		{"Invalid", LuhnInvalid, nil},
+		{"ValidRevealBINLast4", LuhnValid, []Option{WithRevealPrefix(6), WithRevealSuffix(4)}},
+		{"InvalidRevealBIN", LuhnInvalid, []Option{WithRevealPrefix(6)}},
+		{"InvalidRevealBINLast4", LuhnInvalid, []Option{WithRevealPrefix(6), WithRevealSuffix(4)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]Option{WithLuhn(tc.policy)}, tc.opts...)
+			f, err := NewFF1(testKey, []byte("pan"), opts...)
+			if err != nil {
+				t.Fatalf("NewFF1 failed: %v", err)
+			}
+
+			for _, pan := range pans {
+				tokenized, err := f.Tokenize(pan)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", pan, err)
+				}
+				if len(tokenized) != len(pan) {
+					t.Fatalf("Length not preserved: %s -> %s", pan, tokenized)
+				}
+
+				formatMask, digits := SeparateFormatAndData(tokenized)
+				runes := []rune(digits)
+				positions := make([]int, len(runes))
+				for i := range positions {
+					positions[i] = i
+				}
+				if luhnValid(runes, positions) != (tc.policy == LuhnValid) {
+					t.Errorf("Token %s has the wrong Luhn validity for policy %v", tokenized, tc.policy)
+				}
+				if ReconstructWithFormat(digits, formatMask, pan) != tokenized {
+					t.Errorf("Format characters not preserved: %s -> %s", pan, tokenized)
+				}
+
+				detokenized, err := f.Detokenize(tokenized, "", "")
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != pan {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", pan, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestLuhnValidation verifies that invalid inputs and option combinations are rejected.
+func TestLuhnValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithLuhn(LuhnValid))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := tokenizer.Tokenize("4111111111111112"); err == nil {
+		t.Error("Expected an error for a PAN that fails the Luhn check")
+	}
+	if _, err := tokenizer.Detokenize("4111111111111112"); err == nil {
+		t.Error("Expected an error for a token that fails the Luhn check")
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithLuhn(LuhnValid), WithAlphabet(AlphabetHex)); err == nil {
+		t.Error("Expected an error for a non-numeric alphabet")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithLuhn(LuhnValid), WithClassPreservation()); err == nil {
+		t.Error("Expected an error when combined with WithCharacterClasses")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithLuhn(LuhnPolicy(7))); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,76 @@
+package fpe
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// WithMarker reserves the len(marker) characters at index position of every
+// value for a marker, such as the key hint of tinkfpe keys: values must hold
+// fill there and tokens hold marker there instead. Only the other data
+// characters are encrypted, so tokens keep the length of the value and, if
+// marker fits the alphabet of its positions, its format too. Detokenize checks
+// the marker and restores fill.
+//
+// The reserved characters must be data characters of the value. WithMarker
+// works with WithAlphabet, WithCharacterClasses, WithFormat and the reveal
+// options, but not with WithLuhn or WithCheckDigit.
+func WithMarker(position int, marker, fill string) Option {
+	return func(c *config) error {
+		if position < 0 {
+			return fmt.Errorf("marker position cannot be negative: %d", position)
+		}
+		if marker == "" || !utf8.ValidString(marker) || !utf8.ValidString(fill) {
+			return fmt.Errorf("marker must be non-empty UTF-8 text")
+		}
+		if utf8.RuneCountInString(fill) != utf8.RuneCountInString(marker) {
+			return fmt.Errorf("marker %q and fill %q must have the same length", marker, fill)
+		}
+		c.marker, c.markerFill, c.markerPosition = []rune(marker), []rune(fill), position
+		return nil
+	}
+}
+
+// takeMarker checks that s holds the fill (encrypt) or the marker (decrypt) at
+// the marker position, writes the fill there, and returns positions and
+// classes without the marker characters. The caller writes the marker back
+// into tokens.
+func (t *Tokenizer) takeMarker(s []rune, positions []int, classes []*Alphabet, encrypt bool) ([]int, []*Alphabet, error) {
+	marker, fill, start := t.config.marker, t.config.markerFill, t.config.markerPosition
+	if len(s) < start+len(marker) {
+		return nil, nil, fmt.Errorf("value of %d characters is too short for a marker at position %d", len(s), start)
+	}
+	want := fill
+	if !encrypt {
+		want = marker
+	}
+	if got := string(s[start : start+len(marker)]); got != string(want) {
+		if encrypt {
+			return nil, nil, fmt.Errorf("value must hold the spare characters %q at position %d to make room for a marker, got %q", string(want), start, got)
+		}
+		return nil, nil, fmt.Errorf("token does not carry the marker %q at position %d, got %q", string(want), start, got)
+	}
+
+	keptPositions := make([]int, 0, len(positions))
+	keptClasses := make([]*Alphabet, 0, len(classes))
+	for i, p := range positions {
+		if p < start || p >= start+len(marker) {
+			keptPositions = append(keptPositions, p)
+			keptClasses = append(keptClasses, classes[i])
+			continue
+		}
+		if !classes[i].Contains(marker[p-start]) || !classes[i].Contains(fill[p-start]) {
+			return nil, nil, fmt.Errorf("marker %q and fill %q do not fit the data character at position %d", string(marker), string(fill), p)
+		}
+	}
+	if len(positions)-len(keptPositions) != len(marker) {
+		return nil, nil, fmt.Errorf("marker at position %d must cover %d data characters", start, len(marker))
+	}
+	copy(s[start:], fill)
+	return keptPositions, keptClasses, nil
+}
+
+// putMarker writes the marker into the token s.
+func (t *Tokenizer) putMarker(s []rune) {
+	copy(s[t.config.markerPosition:], t.config.marker)
+}
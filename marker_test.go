@@ -0,0 +1,98 @@
+package fpe
+
+import (
+	"testing"
+)
+
+// TestMarker verifies that tokens hold the marker in place of the fill, keep
+// the length and format of the value, and round-trip.
+func TestMarker(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []Option
+		plaintext string
+		marker    string
+		position  int
+	}{
+		{"Alphabet", []Option{WithAlphabet(AlphabetNumeric), WithMarker(0, "7", "0")}, "004512345678", "7", 0},
+		{"Format", []Option{WithFormat(MustParseFormat("ID-AAA-9999")), WithMarker(3, "Z", "X")}, "ID-XBC-1234", "Z", 3},
+		{"Classes", []Option{WithClassPreservation(), WithMarker(6, "k", "a")}, "AB1234a", "k", 6},
+		{"Reveal", []Option{WithAlphabet(AlphabetNumeric), WithMarker(0, "12", "00"), WithRevealSuffix(4)}, "0045-1234-5678", "12", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("tweak"), tc.opts...)
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+
+			tokenized, err := tokenizer.Tokenize(tc.plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if len(tokenized) != len(tc.plaintext) {
+				t.Fatalf("Length not preserved: %q -> %q", tc.plaintext, tokenized)
+			}
+			if got := tokenized[tc.position : tc.position+len(tc.marker)]; got != tc.marker {
+				t.Errorf("Expected marker %q in %s, got %q", tc.marker, tokenized, got)
+			}
+			if format := tokenizer.config.format; format != nil {
+				if err := format.Match(tokenized); err != nil {
+					t.Errorf("Token %s does not match the format: %v", tokenized, err)
+				}
+			}
+
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != tc.plaintext {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", tc.plaintext, tokenized, detokenized)
+			}
+			if _, err := tokenizer.Detokenize(tc.plaintext); err == nil {
+				t.Errorf("Expected Detokenize to reject %s without the marker", tc.plaintext)
+			}
+		})
+	}
+}
+
+// TestMarkerErrors verifies that values without the fill, markers that do not
+// fit the value and conflicting options are rejected.
+func TestMarkerErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		opts  []Option
+		value string
+	}{
+		{"NoFill", []Option{WithAlphabet(AlphabetNumeric), WithMarker(0, "7", "0")}, "104512345678"},
+		{"TooShort", []Option{WithAlphabet(AlphabetNumeric), WithMarker(12, "7", "0")}, "004512345678"},
+		{"NotData", []Option{WithAlphabet(AlphabetNumeric), WithMarker(3, "7", "-")}, "123-45-6789"},
+		{"MarkerOutsideAlphabet", []Option{WithAlphabet(AlphabetNumeric), WithMarker(0, "K", "0")}, "004512345678"},
+		{"MarkerOutsideFormat", []Option{WithFormat(MustParseFormat("999-99-9999")), WithMarker(0, "K", "0")}, "012-45-6789"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, nil, tc.opts...)
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+			if token, err := tokenizer.Tokenize(tc.value); err == nil {
+				t.Errorf("Expected an error for %q, got %q", tc.value, token)
+			}
+		})
+	}
+
+	for _, opts := range [][]Option{
+		{WithMarker(-1, "7", "0")},
+		{WithMarker(0, "", "")},
+		{WithMarker(0, "77", "0")},
+		{WithMarker(0, "7", "0"), WithLuhn(LuhnValid)},
+		{WithMarker(0, "7", "0"), WithCheckDigit(CheckDigitLuhn)},
+		{WithMarker(0, "7", "0"), WithSSN()},
+	} {
+		if _, err := NewFF1Tokenizer(testKey, nil, opts...); err == nil {
+			t.Errorf("Expected an error for options %d", len(opts))
+		}
+	}
+}
@@ -1,15 +1,19 @@
 package fpe
 
 // StringToNumeric converts a string to a numeric representation based on alphabet.
+// Both s and alphabet are interpreted as sequences of characters (runes).
 // This is a high-level utility function used by the public FPE API.
 func StringToNumeric(s, alphabet string) []uint16 {
-	result := make([]uint16, len(s))
+	runes := []rune(s)
+	result := make([]uint16, len(runes))
 	alphabetMap := make(map[rune]int)
-	for i, char := range alphabet {
-		alphabetMap[char] = i
+	idx := 0
+	for _, char := range alphabet {
+		alphabetMap[char] = idx
+		idx++
 	}
 
-	for i, char := range s {
+	for i, char := range runes {
 		if idx, ok := alphabetMap[char]; ok {
 			result[i] = uint16(idx)
 		} else {
@@ -22,14 +26,16 @@ func StringToNumeric(s, alphabet string) []uint16 {
 }
 
 // NumericToString converts a numeric representation back to string based on alphabet.
+// The alphabet is interpreted as a sequence of characters (runes) and length counts characters.
 // This is a high-level utility function used by the public FPE API.
 func NumericToString(numeric []uint16, alphabet string, length int) string {
-	result := make([]byte, length)
+	alphabetRunes := []rune(alphabet)
+	result := make([]rune, length)
 	for i := 0; i < length && i < len(numeric); i++ {
-		if int(numeric[i]) < len(alphabet) {
-			result[i] = alphabet[numeric[i]]
+		if int(numeric[i]) < len(alphabetRunes) {
+			result[i] = alphabetRunes[numeric[i]]
 		} else {
-			result[i] = alphabet[0] // Default to first character
+			result[i] = alphabetRunes[0] // Default to first character
 		}
 	}
 	return string(result)
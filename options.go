@@ -0,0 +1,277 @@
+package fpe
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures how a Tokenizer maps strings to numerals.
+// Options are accepted by NewTokenizer, NewFF1, NewFF31 and tinkfpe.New.
+type Option func(*config) error
+
+// mode is the kind of value a Tokenizer handles. Each option that chooses a
+// mode sets it, and at most one mode can be chosen.
+type mode int
+
+const (
+	modeNone mode = iota
+
+	// The layout modes encrypt the data characters of a value in place
+	modeAlphabet
+	modeClasses
+	modeFormat
+
+	// The other modes parse the whole value themselves
+	modeDate
+	modeEmail
+	modeIP
+	modeUUID
+	modeSSN
+	modeIBAN
+	modePhone
+	modeDictionary
+	modeRegex
+	modeEncoding
+)
+
+// modeOptions names the option that chooses each mode.
+var modeOptions = map[mode]string{
+	modeAlphabet:   "WithAlphabet",
+	modeClasses:    "WithCharacterClasses",
+	modeFormat:     "WithFormat",
+	modeDate:       "WithDate",
+	modeEmail:      "WithEmail",
+	modeIP:         "WithIP",
+	modeUUID:       "WithUUID",
+	modeSSN:        "WithSSN",
+	modeIBAN:       "WithIBAN",
+	modePhone:      "WithPhone",
+	modeDictionary: "WithDictionary",
+	modeRegex:      "WithRegex",
+	modeEncoding:   "WithEncoding",
+}
+
+// String returns the name of the option that chooses m.
+func (m mode) String() string {
+	if name, ok := modeOptions[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("mode(%d)", int(m))
+}
+
+// parsesValue reports whether m parses the whole value itself rather than
+// encrypting data characters in place.
+func (m mode) parsesValue() bool {
+	return m >= modeDate
+}
+
+// config holds the settings applied by Options.
+type config struct {
+	mode mode
+
+	alphabet *Alphabet
+	classes  []*Alphabet
+	format   *Format
+
+	revealPrefix int
+	revealSuffix int
+
+	luhn LuhnPolicy
+
+	marker         []rune
+	markerFill     []rune
+	markerPosition int
+
+	dateLayout    string
+	dateMin       time.Time
+	dateMax       time.Time
+	preserveYear  bool
+	preserveMonth bool
+
+	emailDomain EmailDomainPolicy
+
+	ipMode  IPMode
+	ipKeep4 int
+	ipKeep6 int
+
+	ssnArea9xx bool
+
+	phoneAreaCode PhoneAreaCodePolicy
+
+	dictionary     *Dictionary
+	dictionaryMiss DictionaryMissPolicy
+
+	regex *Regex
+
+	encoding Encoding
+
+	checkDigit CheckDigit
+}
+
+// WithAlphabet fixes the alphabet used for data characters. Every character of
+// the alphabet is encrypted; every other character is a format character and is
+// preserved in place. Because the alphabet no longer depends on the input,
+// Detokenize can invert Tokenize from the token alone.
+func WithAlphabet(alphabet *Alphabet) Option {
+	return func(c *config) error {
+		if alphabet == nil {
+			return fmt.Errorf("alphabet cannot be nil")
+		}
+		if err := c.setMode(modeAlphabet); err != nil {
+			return err
+		}
+		c.alphabet = alphabet
+		return nil
+	}
+}
+
+// WithCharacterClasses makes every data character keep its class: a character
+// of classes[i] is always replaced by another character of classes[i]. Characters
+// outside all classes are format characters and are preserved in place.
+// The classes must not share characters.
+func WithCharacterClasses(classes ...*Alphabet) Option {
+	return func(c *config) error {
+		if len(classes) == 0 {
+			return fmt.Errorf("at least one character class is required")
+		}
+		seen := make(map[rune]int)
+		for i, class := range classes {
+			if class == nil {
+				return fmt.Errorf("character class %d cannot be nil", i)
+			}
+			for _, r := range class.runes {
+				if j, ok := seen[r]; ok {
+					return fmt.Errorf("character %q is in both class %d and class %d", r, j, i)
+				}
+				seen[r] = i
+			}
+		}
+		if err := c.setMode(modeClasses); err != nil {
+			return err
+		}
+		c.classes = classes
+		return nil
+	}
+}
+
+// WithClassPreservation keeps digits as digits, uppercase ASCII letters as
+// uppercase letters and lowercase ASCII letters as lowercase letters, so a value
+// such as "AB12cd" always tokenizes to two uppercase letters, two digits and two
+// lowercase letters. It is WithCharacterClasses(AlphabetNumeric,
+// AlphabetUppercase, AlphabetLowercase).
+func WithClassPreservation() Option {
+	return WithCharacterClasses(AlphabetNumeric, AlphabetUppercase, AlphabetLowercase)
+}
+
+// WithFormat makes every value follow format: only the data positions of the
+// format are encrypted, each within its own alphabet, and values that do not
+// match the format are rejected with a *FormatError.
+func WithFormat(format *Format) Option {
+	return func(c *config) error {
+		if format == nil {
+			return fmt.Errorf("format cannot be nil")
+		}
+		if err := c.setMode(modeFormat); err != nil {
+			return err
+		}
+		c.format = format
+		return nil
+	}
+}
+
+// WithRevealPrefix leaves the first n data characters of every value in the
+// clear, such as the BIN of a card number. Format characters are not counted.
+// The revealed characters are folded into the tweak, so the encrypted middle is
+// bound to them: the same middle tokenizes differently under a different prefix.
+// The middle must still meet the cipher's minimum domain size.
+func WithRevealPrefix(n int) Option {
+	return func(c *config) error {
+		if n < 0 {
+			return fmt.Errorf("reveal prefix cannot be negative: %d", n)
+		}
+		c.revealPrefix = n
+		return nil
+	}
+}
+
+// WithRevealSuffix leaves the last n data characters of every value in the
+// clear, such as the last 4 digits of an SSN. It behaves like WithRevealPrefix.
+func WithRevealSuffix(n int) Option {
+	return func(c *config) error {
+		if n < 0 {
+			return fmt.Errorf("reveal suffix cannot be negative: %d", n)
+		}
+		c.revealSuffix = n
+		return nil
+	}
+}
+
+// setMode chooses the mode m, failing if an option already chose another.
+func (c *config) setMode(m mode) error {
+	if c.mode != modeNone && c.mode != m {
+		return fmt.Errorf("only one of %v and %v can be used", c.mode, m)
+	}
+	c.mode = m
+	return nil
+}
+
+// newConfig applies opts on top of the defaults.
+func newConfig(opts []Option) (*config, error) {
+	c := &config{}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.mode != modeIP && (c.ipKeep4 != 0 || c.ipKeep6 != 0) {
+		return nil, fmt.Errorf("WithIPKeepPrefix requires WithIP")
+	}
+	if c.mode != modeSSN && c.ssnArea9xx {
+		return nil, fmt.Errorf("WithSSNArea9xx requires WithSSN")
+	}
+	if c.mode.parsesValue() && (c.revealPrefix+c.revealSuffix > 0 || c.marker != nil || c.luhn != LuhnNone || c.checkDigit != nil) {
+		return nil, fmt.Errorf("%v cannot be combined with reveal, marker, Luhn or check-digit options", c.mode)
+	}
+	if c.mode != modeDate && (!c.dateMin.IsZero() || c.preserveYear || c.preserveMonth) {
+		return nil, fmt.Errorf("WithDateRange, WithPreserveYear and WithPreserveMonth require WithDate")
+	}
+	if c.mode == modeDate {
+		if c.dateMin.IsZero() {
+			c.dateMin, c.dateMax = defaultDateMin, defaultDateMax
+		}
+		if err := checkDateLayout(c.dateLayout, c.dateMin, c.dateMax); err != nil {
+			return nil, err
+		}
+	}
+	if c.mode.parsesValue() {
+		return c, nil
+	}
+
+	if c.checkDigit != nil && (c.luhn != LuhnNone || c.revealSuffix > 0) {
+		return nil, fmt.Errorf("WithCheckDigit cannot be combined with WithLuhn or WithRevealSuffix")
+	}
+	if c.marker != nil && (c.luhn != LuhnNone || c.checkDigit != nil) {
+		return nil, fmt.Errorf("WithMarker cannot be combined with WithLuhn or WithCheckDigit")
+	}
+
+	if c.luhn != LuhnNone {
+		if c.mode == modeClasses || c.mode == modeFormat {
+			return nil, fmt.Errorf("WithLuhn cannot be combined with WithCharacterClasses or WithFormat")
+		}
+		if c.alphabet == nil {
+			c.alphabet = AlphabetNumeric
+		}
+		if c.alphabet.String() != AlphabetNumeric.String() {
+			return nil, fmt.Errorf("WithLuhn requires the numeric alphabet, got %q", c.alphabet.String())
+		}
+	}
+	if c.alphabet == nil && c.mode == modeNone {
+		// Reveal options alone keep the default alphabet; check digits are digits
+		c.alphabet = AlphabetAlphanumeric
+		if c.checkDigit != nil {
+			c.alphabet = AlphabetNumeric
+		}
+	}
+	return c, nil
+}
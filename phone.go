@@ -0,0 +1,192 @@
+package fpe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// PhoneAreaCodePolicy selects what WithPhone does with the area code of a
+// number.
+type PhoneAreaCodePolicy int
+
+const (
+	// PhoneTokenizeAreaCode tokenizes the whole national significant number.
+	// This is the default.
+	PhoneTokenizeAreaCode PhoneAreaCodePolicy = iota
+
+	// PhoneKeepAreaCode leaves the area code in the clear, so tokens still
+	// show the region of the number. It needs a country whose area codes have
+	// a fixed length, such as +1 (three digits).
+	PhoneKeepAreaCode
+)
+
+// String returns the name of the policy.
+func (p PhoneAreaCodePolicy) String() string {
+	switch p {
+	case PhoneTokenizeAreaCode:
+		return "TokenizeAreaCode"
+	case PhoneKeepAreaCode:
+		return "KeepAreaCode"
+	default:
+		return fmt.Sprintf("PhoneAreaCodePolicy(%d)", int(p))
+	}
+}
+
+const (
+	// maxE164Digits is the maximum number of digits of an E.164 number,
+	// including the country calling code.
+	maxE164Digits = 15
+
+	// phoneSeparators are the characters besides digits allowed after the "+".
+	phoneSeparators = " -.()"
+)
+
+// phoneCountry describes the numbers of a country calling code.
+type phoneCountry struct {
+	// minLength and maxLength bound the length of the national significant number.
+	minLength, maxLength int
+
+	// areaLength is the length of the area code, or 0 if it varies.
+	areaLength int
+
+	// leadingZero is set if national significant numbers may start with 0.
+	leadingZero bool
+}
+
+// phoneCountries maps country calling codes to their national significant
+// number lengths. Calling codes are prefix-free, so a number has at most one.
+var phoneCountries = map[string]phoneCountry{
+	"1": {10, 10, 3, false}, "7": {10, 10, 3, false},
+	"20": {8, 10, 0, false}, "27": {9, 9, 2, false}, "30": {10, 10, 0, false},
+	"31": {9, 9, 0, false}, "32": {8, 9, 0, false}, "33": {9, 9, 1, false},
+	"34": {9, 9, 0, false}, "36": {8, 9, 0, false}, "39": {6, 11, 0, true},
+	"40": {9, 9, 0, false}, "41": {9, 9, 2, false}, "43": {4, 13, 0, false},
+	"44": {9, 10, 0, false}, "45": {8, 8, 0, false}, "46": {7, 10, 0, false},
+	"47": {8, 8, 0, false}, "48": {9, 9, 0, false}, "49": {6, 13, 0, false},
+	"51": {8, 9, 0, false}, "52": {10, 10, 0, false}, "53": {8, 8, 0, false},
+	"54": {10, 10, 0, false}, "55": {10, 11, 2, false}, "56": {9, 9, 0, false},
+	"57": {10, 10, 0, false}, "58": {10, 10, 0, false}, "60": {8, 10, 0, false},
+	"61": {9, 9, 1, false}, "62": {8, 12, 0, false}, "63": {8, 10, 0, false},
+	"64": {8, 10, 0, false}, "65": {8, 8, 0, false}, "66": {8, 9, 0, false},
+	"81": {9, 10, 0, false}, "82": {8, 10, 0, false}, "84": {9, 10, 0, false},
+	"86": {9, 11, 0, false}, "90": {10, 10, 3, false}, "91": {10, 10, 0, false},
+	"92": {9, 10, 0, false}, "93": {9, 9, 0, false}, "94": {9, 9, 0, false},
+	"95": {8, 10, 0, false}, "98": {10, 10, 0, false},
+	"212": {9, 9, 0, false}, "213": {9, 9, 0, false}, "216": {8, 8, 0, false},
+	"234": {8, 10, 0, false}, "254": {9, 9, 0, false}, "255": {9, 9, 0, false},
+	"256": {9, 9, 0, false}, "351": {9, 9, 0, false}, "352": {4, 11, 0, false},
+	"353": {7, 9, 0, false}, "354": {7, 7, 0, false}, "358": {5, 12, 0, false},
+	"359": {8, 9, 0, false}, "370": {8, 8, 0, false}, "371": {8, 8, 0, false},
+	"372": {7, 8, 0, false}, "380": {9, 9, 2, false}, "385": {8, 9, 0, false},
+	"386": {8, 8, 0, false}, "420": {9, 9, 0, false}, "421": {9, 9, 0, false},
+	"852": {8, 8, 0, false}, "853": {8, 8, 0, false}, "880": {10, 10, 0, false},
+	"886": {8, 9, 0, false}, "961": {7, 8, 0, false}, "962": {8, 9, 0, false},
+	"965": {8, 8, 0, false}, "966": {9, 9, 0, false}, "971": {8, 9, 0, false},
+	"972": {8, 9, 0, false}, "974": {8, 8, 0, false},
+}
+
+// Digit classes of national significant numbers.
+var (
+	phoneDigitsNonZero = mustAlphabet("123456789")
+	phoneDigitsNANP    = mustAlphabet("23456789")
+)
+
+// WithPhone makes the Tokenizer treat every value as an international phone
+// number: a "+", the country calling code and the national significant number
+// (NSN), optionally separated by spaces, hyphens, dots and parentheses, as in
+// "+1 (555) 123-4567". Tokens keep the country code, the NSN length and every
+// separator in place, so they still parse as E.164 numbers:
+//
+//   - The country code is found in a built-in table of calling codes, which
+//     also bounds the NSN length. Unknown codes are rejected.
+//   - The NSN never starts with 0 (except in Italy), and North American area
+//     codes never start with 0 or 1.
+//   - With PhoneKeepAreaCode the area code is kept and bound to the rest of the
+//     number through the tweak.
+//
+// The tokenized digits must still meet the cipher's minimum domain size.
+func WithPhone(areaCode PhoneAreaCodePolicy) Option {
+	return func(c *config) error {
+		if areaCode != PhoneTokenizeAreaCode && areaCode != PhoneKeepAreaCode {
+			return fmt.Errorf("unsupported phone area code policy: %v", areaCode)
+		}
+		if err := c.setMode(modePhone); err != nil {
+			return err
+		}
+		c.phoneAreaCode = areaCode
+		return nil
+	}
+}
+
+// transformPhone tokenizes (encrypt) or detokenizes s under WithPhone.
+func (t *Tokenizer) transformPhone(s string, encrypt bool) (string, error) {
+	out := []rune(s)
+	if len(out) == 0 || out[0] != '+' {
+		return "", fmt.Errorf("phone number %q must start with '+' and the country calling code", s)
+	}
+	var digits []int
+	for i, r := range out[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, i+1)
+		case !strings.ContainsRune(phoneSeparators, r):
+			return "", fmt.Errorf("phone number %q contains invalid character %q", s, r)
+		}
+	}
+	if len(digits) > maxE164Digits {
+		return "", fmt.Errorf("phone number %q has %d digits (maximum %d)", s, len(digits), maxE164Digits)
+	}
+
+	code, country, ok := "", phoneCountry{}, false
+	for n := 1; n <= 3 && n < len(digits) && !ok; n++ {
+		code = string(revealed(out, digits[:n]))
+		country, ok = phoneCountries[code]
+	}
+	if !ok {
+		return "", fmt.Errorf("phone number %q has an unsupported country calling code", s)
+	}
+
+	nsn := digits[len(code):]
+	if len(nsn) < country.minLength || len(nsn) > country.maxLength {
+		if country.minLength == country.maxLength {
+			return "", fmt.Errorf("phone number %q must have %d digits after +%s", s, country.minLength, code)
+		}
+		return "", fmt.Errorf("phone number %q must have %d to %d digits after +%s", s, country.minLength, country.maxLength, code)
+	}
+
+	classes := make([]*Alphabet, len(nsn))
+	for i := range classes {
+		classes[i] = AlphabetNumeric
+	}
+	if !country.leadingZero {
+		classes[0] = phoneDigitsNonZero
+	}
+	if code == "1" {
+		classes[0] = phoneDigitsNANP
+	}
+	for i, p := range nsn {
+		if !classes[i].Contains(out[p]) {
+			return "", fmt.Errorf("phone number %q is not valid for +%s: digit %d cannot be %q", s, code, i+1, out[p])
+		}
+	}
+
+	keep := 0
+	if t.config.phoneAreaCode == PhoneKeepAreaCode {
+		if country.areaLength == 0 {
+			return "", fmt.Errorf("cannot keep the area code of +%s numbers: its length varies", code)
+		}
+		keep = country.areaLength
+	}
+	c := bindCipher(t.cipher.(subtle.TweakableCipher), []byte("phone"), []byte(code), revealed(out, nsn[:keep]))
+
+	data, err := cipherClasses(c, classes[keep:], []rune(string(revealed(out, nsn[keep:]))), encrypt)
+	if err != nil {
+		return "", err
+	}
+	for i, p := range nsn[keep:] {
+		out[p] = data[i]
+	}
+	return string(out), nil
+}
@@ -0,0 +1,135 @@
+package fpe
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPhoneTokenization verifies that tokens keep the country code, NSN length
+// and separators, still parse as E.164 numbers, and round-trip.
+func TestPhoneTokenization(t *testing.T) {
+	numbers := []string{
+		"+1 (555) 123-4567",
+		"+15551234567",
+		"+44 20 7946 0958",
+		"+49 30 901820",
+		"+39 06 6982 1234",
+		"+353 1 234 5678",
+		"+86 138 0013 8000",
+		"+61.2.9374.4000",
+	}
+
+	tokenizer, err := NewFF1Tokenizer(testKey, []byte("phone"), WithPhone(PhoneTokenizeAreaCode))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for _, number := range numbers {
+		tokenized, err := tokenizer.Tokenize(number)
+		if err != nil {
+			t.Fatalf("Tokenize(%s) failed: %v", number, err)
+		}
+		if tokenized == number || len(tokenized) != len(number) {
+			t.Errorf("Bad token for %s: %s", number, tokenized)
+		}
+		for i := range number {
+			if (number[i] >= '0' && number[i] <= '9') != (tokenized[i] >= '0' && tokenized[i] <= '9') ||
+				(number[i] < '0' || number[i] > '9') && number[i] != tokenized[i] {
+				t.Errorf("Separators not preserved: %s -> %s", number, tokenized)
+				break
+			}
+		}
+		code := e164Digits(number)[:3]
+		for _, ok := phoneCountries[code]; !ok; _, ok = phoneCountries[code] {
+			code = code[:len(code)-1]
+		}
+		if !strings.HasPrefix(e164Digits(tokenized), code) {
+			t.Errorf("Country code not kept: %s -> %s", number, tokenized)
+		}
+
+		detokenized, err := tokenizer.Detokenize(tokenized)
+		if err != nil {
+			t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+		}
+		if detokenized != number {
+			t.Errorf("Round-trip failed: %s -> %s -> %s", number, tokenized, detokenized)
+		}
+	}
+}
+
+// TestPhoneNANP verifies that North American tokens keep valid area codes, and that PhoneKeepAreaCode keeps the area code.
+func TestPhoneNANP(t *testing.T) {
+	for _, policy := range []PhoneAreaCodePolicy{PhoneTokenizeAreaCode, PhoneKeepAreaCode} {
+		t.Run(policy.String(), func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, nil, WithPhone(policy))
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+
+			for _, number := range []string{"+1 212 555 0100", "+1 415 867 5309", "+1 907 200 0000", "+1 800 999 9999"} {
+				tokenized, err := tokenizer.Tokenize(number)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", number, err)
+				}
+				digits := e164Digits(tokenized)
+				if digits[0] != '1' || digits[1] < '2' {
+					t.Errorf("Token %s is not a valid NANP number", tokenized)
+				}
+				if policy == PhoneKeepAreaCode && digits[1:4] != e164Digits(number)[1:4] {
+					t.Errorf("Area code not kept: %s -> %s", number, tokenized)
+				}
+
+				detokenized, err := tokenizer.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != number {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", number, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestPhoneValidation verifies that invalid numbers are rejected.
+func TestPhoneValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithPhone(PhoneKeepAreaCode))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for _, number := range []string{
+		"",
+		"555-123-4567",        // no country code
+		"+1 555 123 456",      // too short
+		"+1 555 123 45678",    // too long
+		"+1 055 123 4567",     // NANP area code starting with 0
+		"+1 555 123 4567 ext", // letters
+		"+999 1234 5678",      // unknown calling code
+		"+44 020 7946 0958",   // trunk prefix in the NSN
+		"+44 20 7946 0958",    // area code length varies in the UK
+		"+1234567890123456",   // more than 15 digits
+	} {
+		if _, err := tokenizer.Tokenize(number); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", number)
+		}
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithPhone(PhoneAreaCodePolicy(5))); err == nil {
+		t.Error("Expected an unknown area code policy to be rejected")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithPhone(PhoneTokenizeAreaCode), WithRevealSuffix(4)); err == nil {
+		t.Error("Expected WithPhone and reveal options to be exclusive")
+	}
+}
+
+// e164Digits returns the digits of a phone number.
+func e164Digits(s string) string {
+	var digits []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits = append(digits, s[i])
+		}
+	}
+	return string(digits)
+}
@@ -0,0 +1,960 @@
+// Key formats of the FPE key managers in the tinkfpe package.
+//
+// Regenerate fpe_go_proto/fpe.pb.go with protoc-gen-go v1.27.1 after editing
+// this file, from the repository root and with a Tink checkout providing
+// proto/tink.proto:
+//
+//   protoc -I . -I $TINK --go_out=. \
+//     --go_opt=module=github.com/vdparikh/fpe \
+//     --go_opt=Mproto/tink.proto=github.com/google/tink/go/proto/tink_go_proto \
+//     proto/fpe.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: proto/fpe.proto
+
+package fpe_go_proto
+
+import (
+	tink_go_proto "github.com/google/tink/go/proto/tink_go_proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FpeAlgorithm is the cipher of an FPE key. It must match the key type.
+type FpeAlgorithm int32
+
+const (
+	FpeAlgorithm_FPE_ALGORITHM_UNSPECIFIED FpeAlgorithm = 0
+	FpeAlgorithm_FPE_ALGORITHM_FF1         FpeAlgorithm = 1
+	FpeAlgorithm_FPE_ALGORITHM_FF3_1       FpeAlgorithm = 2
+)
+
+// Enum value maps for FpeAlgorithm.
+var (
+	FpeAlgorithm_name = map[int32]string{
+		0: "FPE_ALGORITHM_UNSPECIFIED",
+		1: "FPE_ALGORITHM_FF1",
+		2: "FPE_ALGORITHM_FF3_1",
+	}
+	FpeAlgorithm_value = map[string]int32{
+		"FPE_ALGORITHM_UNSPECIFIED": 0,
+		"FPE_ALGORITHM_FF1":         1,
+		"FPE_ALGORITHM_FF3_1":       2,
+	}
+)
+
+func (x FpeAlgorithm) Enum() *FpeAlgorithm {
+	p := new(FpeAlgorithm)
+	*p = x
+	return p
+}
+
+func (x FpeAlgorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FpeAlgorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_fpe_proto_enumTypes[0].Descriptor()
+}
+
+func (FpeAlgorithm) Type() protoreflect.EnumType {
+	return &file_proto_fpe_proto_enumTypes[0]
+}
+
+func (x FpeAlgorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FpeAlgorithm.Descriptor instead.
+func (FpeAlgorithm) EnumDescriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{0}
+}
+
+// FpeTweakPolicy selects where the tweak of an FPE primitive comes from.
+type FpeTweakPolicy int32
+
+const (
+	// The caller passes the tweak when creating the primitive.
+	FpeTweakPolicy_FPE_TWEAK_POLICY_CALLER FpeTweakPolicy = 0
+	// The tweak is FpeParams.tweak; callers pass none or the same tweak.
+	FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED FpeTweakPolicy = 1
+)
+
+// Enum value maps for FpeTweakPolicy.
+var (
+	FpeTweakPolicy_name = map[int32]string{
+		0: "FPE_TWEAK_POLICY_CALLER",
+		1: "FPE_TWEAK_POLICY_FIXED",
+	}
+	FpeTweakPolicy_value = map[string]int32{
+		"FPE_TWEAK_POLICY_CALLER": 0,
+		"FPE_TWEAK_POLICY_FIXED":  1,
+	}
+)
+
+func (x FpeTweakPolicy) Enum() *FpeTweakPolicy {
+	p := new(FpeTweakPolicy)
+	*p = x
+	return p
+}
+
+func (x FpeTweakPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FpeTweakPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_fpe_proto_enumTypes[1].Descriptor()
+}
+
+func (FpeTweakPolicy) Type() protoreflect.EnumType {
+	return &file_proto_fpe_proto_enumTypes[1]
+}
+
+func (x FpeTweakPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FpeTweakPolicy.Descriptor instead.
+func (FpeTweakPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{1}
+}
+
+// FpeParams are the parameters of an FPE key.
+type FpeParams struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Algorithm FpeAlgorithm `protobuf:"varint,1,opt,name=algorithm,proto3,enum=google.crypto.tink.FpeAlgorithm" json:"algorithm,omitempty"`
+	// Characters of the alphabet of data characters, in order. Empty leaves the
+	// alphabet to the caller.
+	Alphabet string `protobuf:"bytes,2,opt,name=alphabet,proto3" json:"alphabet,omitempty"`
+	// Template of the format of every value, such as "###-##-####" (see
+	// fpe.ParseFormat). At most one of alphabet and format can be set. With a
+	// key hint, the hint position must be a constant spare data character of
+	// the format that every value holds as the hint's fill.
+	Format      string         `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	TweakPolicy FpeTweakPolicy `protobuf:"varint,4,opt,name=tweak_policy,json=tweakPolicy,proto3,enum=google.crypto.tink.FpeTweakPolicy" json:"tweak_policy,omitempty"`
+	// Tweak used with FPE_TWEAK_POLICY_FIXED; must be empty otherwise. FF3-1
+	// tweaks are 7 bytes.
+	Tweak []byte `protobuf:"bytes,5,opt,name=tweak,proto3" json:"tweak,omitempty"`
+	// Makes the tokens of the key self-identifying. Unset gives plain tokens.
+	KeyHint *FpeKeyHint `protobuf:"bytes,6,opt,name=key_hint,json=keyHint,proto3" json:"key_hint,omitempty"`
+}
+
+func (x *FpeParams) Reset() {
+	*x = FpeParams{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeParams) ProtoMessage() {}
+
+func (x *FpeParams) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeParams.ProtoReflect.Descriptor instead.
+func (*FpeParams) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FpeParams) GetAlgorithm() FpeAlgorithm {
+	if x != nil {
+		return x.Algorithm
+	}
+	return FpeAlgorithm_FPE_ALGORITHM_UNSPECIFIED
+}
+
+func (x *FpeParams) GetAlphabet() string {
+	if x != nil {
+		return x.Alphabet
+	}
+	return ""
+}
+
+func (x *FpeParams) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *FpeParams) GetTweakPolicy() FpeTweakPolicy {
+	if x != nil {
+		return x.TweakPolicy
+	}
+	return FpeTweakPolicy_FPE_TWEAK_POLICY_CALLER
+}
+
+func (x *FpeParams) GetTweak() []byte {
+	if x != nil {
+		return x.Tweak
+	}
+	return nil
+}
+
+func (x *FpeParams) GetKeyHint() *FpeKeyHint {
+	if x != nil {
+		return x.KeyHint
+	}
+	return nil
+}
+
+// FpeKeyHint is the FPE counterpart of Tink's output prefix, which does not
+// fit in a format-preserving token: the hint takes over spare data characters
+// of every value at position, so the key of a token can be found from the
+// token alone. Values must hold fill there, tokens hold the hint instead, and
+// only the other data characters are encrypted, so tokens keep the length and
+// format of their plaintext (see fpe.WithMarker). The position must be a
+// constant "spare" character of the format: values that do not hold fill there
+// are rejected, so only give a key a hint if every value shares it.
+type FpeKeyHint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Characters identifying the key, such as "1" or "B". The keys of a keyset
+	// need distinct hints of the same length at the same position.
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// Index, in characters, of the data characters the hint takes over.
+	Position uint32 `protobuf:"varint,2,opt,name=position,proto3" json:"position,omitempty"`
+	// Characters every value holds at position, such as the leading "0" of a
+	// zero-padded account number; Detokenize restores them. Same length as value.
+	Fill string `protobuf:"bytes,3,opt,name=fill,proto3" json:"fill,omitempty"`
+}
+
+func (x *FpeKeyHint) Reset() {
+	*x = FpeKeyHint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeKeyHint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeKeyHint) ProtoMessage() {}
+
+func (x *FpeKeyHint) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeKeyHint.ProtoReflect.Descriptor instead.
+func (*FpeKeyHint) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FpeKeyHint) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *FpeKeyHint) GetPosition() uint32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *FpeKeyHint) GetFill() string {
+	if x != nil {
+		return x.Fill
+	}
+	return ""
+}
+
+// FpeFf1KeyFormat is the key format of FF1 keys.
+type FpeFf1KeyFormat struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Params *FpeParams `protobuf:"bytes,1,opt,name=params,proto3" json:"params,omitempty"`
+	// Size of the AES key in bytes: 16, 24 or 32.
+	KeySize uint32 `protobuf:"varint,2,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
+	Version uint32 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *FpeFf1KeyFormat) Reset() {
+	*x = FpeFf1KeyFormat{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeFf1KeyFormat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeFf1KeyFormat) ProtoMessage() {}
+
+func (x *FpeFf1KeyFormat) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeFf1KeyFormat.ProtoReflect.Descriptor instead.
+func (*FpeFf1KeyFormat) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FpeFf1KeyFormat) GetParams() *FpeParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *FpeFf1KeyFormat) GetKeySize() uint32 {
+	if x != nil {
+		return x.KeySize
+	}
+	return 0
+}
+
+func (x *FpeFf1KeyFormat) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// FpeFf1Key is an FF1 key (NIST SP 800-38G).
+//
+// key_type: type.googleapis.com/google.crypto.tink.FpeFf1Key
+type FpeFf1Key struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version uint32     `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Params  *FpeParams `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	// The AES key: 16, 24 or 32 bytes.
+	KeyValue []byte `protobuf:"bytes,3,opt,name=key_value,json=keyValue,proto3" json:"key_value,omitempty"`
+}
+
+func (x *FpeFf1Key) Reset() {
+	*x = FpeFf1Key{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeFf1Key) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeFf1Key) ProtoMessage() {}
+
+func (x *FpeFf1Key) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeFf1Key.ProtoReflect.Descriptor instead.
+func (*FpeFf1Key) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FpeFf1Key) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FpeFf1Key) GetParams() *FpeParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *FpeFf1Key) GetKeyValue() []byte {
+	if x != nil {
+		return x.KeyValue
+	}
+	return nil
+}
+
+// FpeFf31KeyFormat is the key format of FF3-1 keys.
+type FpeFf31KeyFormat struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Params *FpeParams `protobuf:"bytes,1,opt,name=params,proto3" json:"params,omitempty"`
+	// Size of the AES key in bytes: 16, 24 or 32.
+	KeySize uint32 `protobuf:"varint,2,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
+	Version uint32 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *FpeFf31KeyFormat) Reset() {
+	*x = FpeFf31KeyFormat{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeFf31KeyFormat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeFf31KeyFormat) ProtoMessage() {}
+
+func (x *FpeFf31KeyFormat) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeFf31KeyFormat.ProtoReflect.Descriptor instead.
+func (*FpeFf31KeyFormat) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FpeFf31KeyFormat) GetParams() *FpeParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *FpeFf31KeyFormat) GetKeySize() uint32 {
+	if x != nil {
+		return x.KeySize
+	}
+	return 0
+}
+
+func (x *FpeFf31KeyFormat) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// FpeFf31Key is an FF3-1 key (NIST SP 800-38G Rev. 1).
+//
+// key_type: type.googleapis.com/google.crypto.tink.FpeFf31Key
+type FpeFf31Key struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version uint32     `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Params  *FpeParams `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	// The AES key: 16, 24 or 32 bytes.
+	KeyValue []byte `protobuf:"bytes,3,opt,name=key_value,json=keyValue,proto3" json:"key_value,omitempty"`
+}
+
+func (x *FpeFf31Key) Reset() {
+	*x = FpeFf31Key{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeFf31Key) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeFf31Key) ProtoMessage() {}
+
+func (x *FpeFf31Key) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeFf31Key.ProtoReflect.Descriptor instead.
+func (*FpeFf31Key) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FpeFf31Key) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FpeFf31Key) GetParams() *FpeParams {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *FpeFf31Key) GetKeyValue() []byte {
+	if x != nil {
+		return x.KeyValue
+	}
+	return nil
+}
+
+// FpeKmsEnvelopeKeyFormat is the key format of FPE keys wrapped by a key
+// encryption key (KEK) in a remote KMS.
+type FpeKmsEnvelopeKeyFormat struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// URI of the KEK, resolved with the KMS clients registered with Tink.
+	KekUri string `protobuf:"bytes,1,opt,name=kek_uri,json=kekUri,proto3" json:"kek_uri,omitempty"`
+	// Template of the wrapped FF1 or FF3-1 key.
+	DekTemplate *tink_go_proto.KeyTemplate `protobuf:"bytes,2,opt,name=dek_template,json=dekTemplate,proto3" json:"dek_template,omitempty"`
+}
+
+func (x *FpeKmsEnvelopeKeyFormat) Reset() {
+	*x = FpeKmsEnvelopeKeyFormat{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeKmsEnvelopeKeyFormat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeKmsEnvelopeKeyFormat) ProtoMessage() {}
+
+func (x *FpeKmsEnvelopeKeyFormat) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeKmsEnvelopeKeyFormat.ProtoReflect.Descriptor instead.
+func (*FpeKmsEnvelopeKeyFormat) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FpeKmsEnvelopeKeyFormat) GetKekUri() string {
+	if x != nil {
+		return x.KekUri
+	}
+	return ""
+}
+
+func (x *FpeKmsEnvelopeKeyFormat) GetDekTemplate() *tink_go_proto.KeyTemplate {
+	if x != nil {
+		return x.DekTemplate
+	}
+	return nil
+}
+
+// FpeKmsEnvelopeKey is an FPE key with REMOTE key material: the serialized
+// KeyData of an FF1 or FF3-1 key, encrypted with the KEK.
+//
+// key_type: type.googleapis.com/google.crypto.tink.FpeKmsEnvelopeKey
+type FpeKmsEnvelopeKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version uint32                   `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Params  *FpeKmsEnvelopeKeyFormat `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	// KeyData of the wrapped key, encrypted with the KEK using the type URL of
+	// this key as associated data.
+	EncryptedKeyData []byte `protobuf:"bytes,3,opt,name=encrypted_key_data,json=encryptedKeyData,proto3" json:"encrypted_key_data,omitempty"`
+}
+
+func (x *FpeKmsEnvelopeKey) Reset() {
+	*x = FpeKmsEnvelopeKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_fpe_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FpeKmsEnvelopeKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FpeKmsEnvelopeKey) ProtoMessage() {}
+
+func (x *FpeKmsEnvelopeKey) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_fpe_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FpeKmsEnvelopeKey.ProtoReflect.Descriptor instead.
+func (*FpeKmsEnvelopeKey) Descriptor() ([]byte, []int) {
+	return file_proto_fpe_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FpeKmsEnvelopeKey) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *FpeKmsEnvelopeKey) GetParams() *FpeKmsEnvelopeKeyFormat {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *FpeKmsEnvelopeKey) GetEncryptedKeyData() []byte {
+	if x != nil {
+		return x.EncryptedKeyData
+	}
+	return nil
+}
+
+var File_proto_fpe_proto protoreflect.FileDescriptor
+
+var file_proto_fpe_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x66, 0x70, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x12, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f,
+	0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x1a, 0x10, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x69, 0x6e,
+	0x6b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x97, 0x02, 0x0a, 0x09, 0x46, 0x70, 0x65, 0x50,
+	0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x3e, 0x0a, 0x09, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74,
+	0x68, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70,
+	0x65, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x52, 0x09, 0x61, 0x6c, 0x67, 0x6f,
+	0x72, 0x69, 0x74, 0x68, 0x6d, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x62, 0x65,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x62, 0x65,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x45, 0x0a, 0x0c, 0x74, 0x77, 0x65,
+	0x61, 0x6b, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x22, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e,
+	0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70, 0x65, 0x54, 0x77, 0x65, 0x61, 0x6b, 0x50, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x52, 0x0b, 0x74, 0x77, 0x65, 0x61, 0x6b, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x77, 0x65, 0x61, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x74, 0x77, 0x65, 0x61, 0x6b, 0x12, 0x39, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x68, 0x69,
+	0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70,
+	0x65, 0x4b, 0x65, 0x79, 0x48, 0x69, 0x6e, 0x74, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x48, 0x69, 0x6e,
+	0x74, 0x22, 0x52, 0x0a, 0x0a, 0x46, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x48, 0x69, 0x6e, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x66, 0x69, 0x6c, 0x6c, 0x22, 0x7d, 0x0a, 0x0f, 0x46, 0x70, 0x65, 0x46, 0x66, 0x31, 0x4b,
+	0x65, 0x79, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x35, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61,
+	0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70,
+	0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12,
+	0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x22, 0x79, 0x0a, 0x09, 0x46, 0x70, 0x65, 0x46, 0x66, 0x31, 0x4b, 0x65,
+	0x79, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x06, 0x70,
+	0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b,
+	0x2e, 0x46, 0x70, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61,
+	0x6d, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6b, 0x65, 0x79, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x7e, 0x0a, 0x10, 0x46, 0x70, 0x65, 0x46, 0x66, 0x33, 0x31, 0x4b, 0x65, 0x79, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x12, 0x35, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70, 0x65, 0x50, 0x61, 0x72, 0x61,
+	0x6d, 0x73, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65,
+	0x79, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6b, 0x65,
+	0x79, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x7a, 0x0a, 0x0a, 0x46, 0x70, 0x65, 0x46, 0x66, 0x33, 0x31, 0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70, 0x65,
+	0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x1b,
+	0x0a, 0x09, 0x6b, 0x65, 0x79, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x08, 0x6b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x76, 0x0a, 0x17, 0x46,
+	0x70, 0x65, 0x4b, 0x6d, 0x73, 0x45, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x4b, 0x65, 0x79,
+	0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x6b, 0x65, 0x6b, 0x5f, 0x75, 0x72,
+	0x69, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6b, 0x65, 0x6b, 0x55, 0x72, 0x69, 0x12,
+	0x42, 0x0a, 0x0c, 0x64, 0x65, 0x6b, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63,
+	0x72, 0x79, 0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x4b, 0x65, 0x79, 0x54, 0x65,
+	0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x0b, 0x64, 0x65, 0x6b, 0x54, 0x65, 0x6d, 0x70, 0x6c,
+	0x61, 0x74, 0x65, 0x22, 0xa0, 0x01, 0x0a, 0x11, 0x46, 0x70, 0x65, 0x4b, 0x6d, 0x73, 0x45, 0x6e,
+	0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x43, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x63, 0x72, 0x79,
+	0x70, 0x74, 0x6f, 0x2e, 0x74, 0x69, 0x6e, 0x6b, 0x2e, 0x46, 0x70, 0x65, 0x4b, 0x6d, 0x73, 0x45,
+	0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x4b, 0x65, 0x79, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x65, 0x6e, 0x63, 0x72,
+	0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x4b,
+	0x65, 0x79, 0x44, 0x61, 0x74, 0x61, 0x2a, 0x5d, 0x0a, 0x0c, 0x46, 0x70, 0x65, 0x41, 0x6c, 0x67,
+	0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x12, 0x1d, 0x0a, 0x19, 0x46, 0x50, 0x45, 0x5f, 0x41, 0x4c,
+	0x47, 0x4f, 0x52, 0x49, 0x54, 0x48, 0x4d, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x46, 0x50, 0x45, 0x5f, 0x41, 0x4c, 0x47,
+	0x4f, 0x52, 0x49, 0x54, 0x48, 0x4d, 0x5f, 0x46, 0x46, 0x31, 0x10, 0x01, 0x12, 0x17, 0x0a, 0x13,
+	0x46, 0x50, 0x45, 0x5f, 0x41, 0x4c, 0x47, 0x4f, 0x52, 0x49, 0x54, 0x48, 0x4d, 0x5f, 0x46, 0x46,
+	0x33, 0x5f, 0x31, 0x10, 0x02, 0x2a, 0x49, 0x0a, 0x0e, 0x46, 0x70, 0x65, 0x54, 0x77, 0x65, 0x61,
+	0x6b, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1b, 0x0a, 0x17, 0x46, 0x50, 0x45, 0x5f, 0x54,
+	0x57, 0x45, 0x41, 0x4b, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x43, 0x41, 0x4c, 0x4c,
+	0x45, 0x52, 0x10, 0x00, 0x12, 0x1a, 0x0a, 0x16, 0x46, 0x50, 0x45, 0x5f, 0x54, 0x57, 0x45, 0x41,
+	0x4b, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x46, 0x49, 0x58, 0x45, 0x44, 0x10, 0x01,
+	0x42, 0x2c, 0x5a, 0x2a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76,
+	0x64, 0x70, 0x61, 0x72, 0x69, 0x6b, 0x68, 0x2f, 0x66, 0x70, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2f, 0x66, 0x70, 0x65, 0x5f, 0x67, 0x6f, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_fpe_proto_rawDescOnce sync.Once
+	file_proto_fpe_proto_rawDescData = file_proto_fpe_proto_rawDesc
+)
+
+func file_proto_fpe_proto_rawDescGZIP() []byte {
+	file_proto_fpe_proto_rawDescOnce.Do(func() {
+		file_proto_fpe_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_fpe_proto_rawDescData)
+	})
+	return file_proto_fpe_proto_rawDescData
+}
+
+var file_proto_fpe_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_fpe_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_fpe_proto_goTypes = []interface{}{
+	(FpeAlgorithm)(0),                 // 0: google.crypto.tink.FpeAlgorithm
+	(FpeTweakPolicy)(0),               // 1: google.crypto.tink.FpeTweakPolicy
+	(*FpeParams)(nil),                 // 2: google.crypto.tink.FpeParams
+	(*FpeKeyHint)(nil),                // 3: google.crypto.tink.FpeKeyHint
+	(*FpeFf1KeyFormat)(nil),           // 4: google.crypto.tink.FpeFf1KeyFormat
+	(*FpeFf1Key)(nil),                 // 5: google.crypto.tink.FpeFf1Key
+	(*FpeFf31KeyFormat)(nil),          // 6: google.crypto.tink.FpeFf31KeyFormat
+	(*FpeFf31Key)(nil),                // 7: google.crypto.tink.FpeFf31Key
+	(*FpeKmsEnvelopeKeyFormat)(nil),   // 8: google.crypto.tink.FpeKmsEnvelopeKeyFormat
+	(*FpeKmsEnvelopeKey)(nil),         // 9: google.crypto.tink.FpeKmsEnvelopeKey
+	(*tink_go_proto.KeyTemplate)(nil), // 10: google.crypto.tink.KeyTemplate
+}
+var file_proto_fpe_proto_depIdxs = []int32{
+	0,  // 0: google.crypto.tink.FpeParams.algorithm:type_name -> google.crypto.tink.FpeAlgorithm
+	1,  // 1: google.crypto.tink.FpeParams.tweak_policy:type_name -> google.crypto.tink.FpeTweakPolicy
+	3,  // 2: google.crypto.tink.FpeParams.key_hint:type_name -> google.crypto.tink.FpeKeyHint
+	2,  // 3: google.crypto.tink.FpeFf1KeyFormat.params:type_name -> google.crypto.tink.FpeParams
+	2,  // 4: google.crypto.tink.FpeFf1Key.params:type_name -> google.crypto.tink.FpeParams
+	2,  // 5: google.crypto.tink.FpeFf31KeyFormat.params:type_name -> google.crypto.tink.FpeParams
+	2,  // 6: google.crypto.tink.FpeFf31Key.params:type_name -> google.crypto.tink.FpeParams
+	10, // 7: google.crypto.tink.FpeKmsEnvelopeKeyFormat.dek_template:type_name -> google.crypto.tink.KeyTemplate
+	8,  // 8: google.crypto.tink.FpeKmsEnvelopeKey.params:type_name -> google.crypto.tink.FpeKmsEnvelopeKeyFormat
+	9,  // [9:9] is the sub-list for method output_type
+	9,  // [9:9] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_proto_fpe_proto_init() }
+func file_proto_fpe_proto_init() {
+	if File_proto_fpe_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_fpe_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeParams); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeKeyHint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeFf1KeyFormat); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeFf1Key); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeFf31KeyFormat); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeFf31Key); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeKmsEnvelopeKeyFormat); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_fpe_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FpeKmsEnvelopeKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_fpe_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_fpe_proto_goTypes,
+		DependencyIndexes: file_proto_fpe_proto_depIdxs,
+		EnumInfos:         file_proto_fpe_proto_enumTypes,
+		MessageInfos:      file_proto_fpe_proto_msgTypes,
+	}.Build()
+	File_proto_fpe_proto = out.File
+	file_proto_fpe_proto_rawDesc = nil
+	file_proto_fpe_proto_goTypes = nil
+	file_proto_fpe_proto_depIdxs = nil
+}
@@ -0,0 +1,97 @@
+package fpe
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestEncryptRange verifies that values stay within their range, round-trip,
+// and that the mapping is a permutation of a small range.
+func TestEncryptRange(t *testing.T) {
+	f, err := NewFF1(testKey, []byte("range"))
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		min, max int64
+		values   []int64
+	}{
+		{"AccountNumber", 0, 3999999999, []int64{0, 1, 1234567890, 3999999999}},
+		{"Age", 0, 120, []int64{0, 18, 65, 120}},
+		{"Offset", 1000000, 1999999, []int64{1000000, 1500000, 1999999}},
+		{"Negative", -500, 500, []int64{-500, 0, 500}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			min, max := big.NewInt(tc.min), big.NewInt(tc.max)
+			for _, v := range tc.values {
+				x := big.NewInt(v)
+				y, err := f.EncryptRange(x, min, max)
+				if err != nil {
+					t.Fatalf("EncryptRange(%d) failed: %v", v, err)
+				}
+				if y.Cmp(min) < 0 || y.Cmp(max) > 0 {
+					t.Errorf("EncryptRange(%d) = %s is outside [%d, %d]", v, y, tc.min, tc.max)
+				}
+				z, err := f.DecryptRange(y, min, max)
+				if err != nil {
+					t.Fatalf("DecryptRange(%s) failed: %v", y, err)
+				}
+				if z.Cmp(x) != 0 {
+					t.Errorf("Round-trip failed: %d -> %s -> %s", v, y, z)
+				}
+			}
+		})
+	}
+
+	// Every age maps to a distinct age
+	seen := make(map[int64]bool)
+	for v := int64(0); v <= 120; v++ {
+		y, err := f.EncryptRange(big.NewInt(v), big.NewInt(0), big.NewInt(120))
+		if err != nil {
+			t.Fatalf("EncryptRange(%d) failed: %v", v, err)
+		}
+		if seen[y.Int64()] {
+			t.Fatalf("EncryptRange is not a permutation: %s repeated", y)
+		}
+		seen[y.Int64()] = true
+	}
+}
+
+// TestEncryptRangeErrors verifies invalid and pathological ranges are rejected.
+func TestEncryptRangeErrors(t *testing.T) {
+	f, err := NewFF1(testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+	ff31, err := NewFF31(testKey, []byte("tweak-7"))
+	if err != nil {
+		t.Fatalf("NewFF31 failed: %v", err)
+	}
+
+	if _, err := f.EncryptRange(big.NewInt(5), big.NewInt(10), big.NewInt(1)); err == nil {
+		t.Error("Expected an error for min > max")
+	}
+	if _, err := f.EncryptRange(big.NewInt(11), big.NewInt(0), big.NewInt(10)); err == nil {
+		t.Error("Expected an error for a value outside the range")
+	}
+	// A single value needs 1024 / 1 steps on average with FF1
+	if _, err := f.EncryptRange(big.NewInt(7), big.NewInt(7), big.NewInt(7)); err == nil {
+		t.Error("Expected an error for a pathologically small range")
+	}
+	// FF3-1 widens to 2^20 values, so 0..120 is pathological there
+	if _, err := ff31.EncryptRange(big.NewInt(7), big.NewInt(0), big.NewInt(120)); err == nil {
+		t.Error("Expected an error for a range far below the FF3-1 minimum domain")
+	}
+
+	legacy, err := NewFF1Legacy(testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFF1Legacy failed: %v", err)
+	}
+	if _, err := legacy.EncryptRange(big.NewInt(1), big.NewInt(0), big.NewInt(9999)); err == nil {
+		t.Error("Expected EncryptRange to be unavailable in legacy mode")
+	}
+}
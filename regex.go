@@ -0,0 +1,448 @@
+package fpe
+
+import (
+	"fmt"
+	"math/big"
+	"regexp/syntax"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// maxRegexStates bounds the size of the DFA a Regex compiles to.
+const maxRegexStates = 10000
+
+// runeRange is an inclusive range of runes.
+type runeRange struct {
+	lo, hi rune
+}
+
+// Regex is a format defined by a regular expression, compiled to a DFA that
+// can count, rank and unrank the strings of each length it matches.
+// WithRegex tokenizes within it: a value is mapped to its rank among the
+// matching strings of its length, the rank is encrypted, and the string with
+// the resulting rank is returned, so tokens always match the expression.
+// A Regex is immutable and safe for concurrent use.
+type Regex struct {
+	expr      string
+	maxLength int
+
+	// atoms partition the runes the expression can match, in rune order, so
+	// that every state treats all runes of an atom alike.
+	atoms []runeRange
+
+	// next[q][a] is the state after atom a in state q, or -1.
+	next   [][]int
+	accept []bool
+
+	// counts[q][n] is the number of strings of length n accepted from state q.
+	counts [][]*big.Int
+}
+
+// CompileRegex compiles a regular expression in the syntax of the regexp
+// package (for example `[A-Z]{2}\d{6}[A-HJ-NP-Z]`) for values of up to
+// maxLength characters. The expression always matches whole values; a leading
+// ^ and trailing $ are allowed but not needed.
+//
+// Only regular constructs can be used: no word boundaries or other anchors,
+// and no . or \C, whose alphabets would be all of Unicode; use an explicit
+// character class instead. Negated classes never match UTF-16 surrogates.
+func CompileRegex(expr string, maxLength int) (*Regex, error) {
+	if maxLength < 1 {
+		return nil, fmt.Errorf("maximum length must be positive, got %d", maxLength)
+	}
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	re = stripAnchors(re)
+	if err := checkRegular(re); err != nil {
+		return nil, fmt.Errorf("unsupported regular expression %q: %w", expr, err)
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	r := &Regex{expr: expr, maxLength: maxLength}
+	ranges := make(map[int][]runeRange)
+	for pc, inst := range prog.Inst {
+		if inst.Op == syntax.InstRune || inst.Op == syntax.InstRune1 {
+			ranges[pc] = instRanges(&prog.Inst[pc])
+		}
+	}
+	r.atoms = partitionRanges(ranges)
+	if err := r.buildDFA(prog, ranges); err != nil {
+		return nil, fmt.Errorf("unsupported regular expression %q: %w", expr, err)
+	}
+	r.countStrings()
+	return r, nil
+}
+
+// MustCompileRegex is like CompileRegex but panics on error. It is intended
+// for package-level variables.
+func MustCompileRegex(expr string, maxLength int) *Regex {
+	r, err := CompileRegex(expr, maxLength)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// String returns the regular expression r was compiled from.
+func (r *Regex) String() string {
+	return r.expr
+}
+
+// MaxLength returns the maximum length of values, in characters.
+func (r *Regex) MaxLength() int {
+	return r.maxLength
+}
+
+// Match reports whether r matches all of s.
+func (r *Regex) Match(s string) bool {
+	q := 0
+	for _, c := range s {
+		a := r.atom(c)
+		if a < 0 || r.next[q][a] < 0 {
+			return false
+		}
+		q = r.next[q][a]
+	}
+	return r.accept[q]
+}
+
+// Count returns the number of strings of n characters that r matches.
+func (r *Regex) Count(n int) *big.Int {
+	if n < 0 || n > r.maxLength {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(r.counts[0][n])
+}
+
+// Rank returns the position of s, in rune order, among the strings of its
+// length that r matches.
+func (r *Regex) Rank(s string) (*big.Int, error) {
+	runes := []rune(s)
+	n := len(runes)
+	if n > r.maxLength {
+		return nil, fmt.Errorf("value has %d characters, more than the maximum of %d", n, r.maxLength)
+	}
+
+	rank := new(big.Int)
+	term := new(big.Int)
+	q := 0
+	for i, c := range runes {
+		a := r.atom(c)
+		if a < 0 || r.next[q][a] < 0 {
+			return nil, fmt.Errorf("value %q does not match %q at character %d", s, r.expr, i+1)
+		}
+		// Count the strings that differ first at position i with a smaller rune
+		remaining := n - i - 1
+		for b := 0; b < a; b++ {
+			if p := r.next[q][b]; p >= 0 {
+				term.SetInt64(int64(r.atoms[b].hi - r.atoms[b].lo + 1))
+				rank.Add(rank, term.Mul(term, r.counts[p][remaining]))
+			}
+		}
+		q = r.next[q][a]
+		term.SetInt64(int64(c - r.atoms[a].lo))
+		rank.Add(rank, term.Mul(term, r.counts[q][remaining]))
+	}
+	if !r.accept[q] {
+		return nil, fmt.Errorf("value %q does not match %q", s, r.expr)
+	}
+	return rank, nil
+}
+
+// Unrank returns the string of n characters with the given rank; it inverts Rank.
+func (r *Regex) Unrank(n int, rank *big.Int) (string, error) {
+	if rank.Sign() < 0 || rank.Cmp(r.Count(n)) >= 0 {
+		return "", fmt.Errorf("rank %s is out of range for length %d", rank.String(), n)
+	}
+
+	x := new(big.Int).Set(rank)
+	out := make([]rune, n)
+	block, offset := new(big.Int), new(big.Int)
+	q := 0
+	for i := range out {
+		remaining := n - i - 1
+		for a, atom := range r.atoms {
+			p := r.next[q][a]
+			if p < 0 {
+				continue
+			}
+			count := r.counts[p][remaining]
+			block.Mul(big.NewInt(int64(atom.hi-atom.lo+1)), count)
+			if x.Cmp(block) >= 0 {
+				x.Sub(x, block)
+				continue
+			}
+			offset.DivMod(x, count, x)
+			out[i] = atom.lo + rune(offset.Int64())
+			q = p
+			break
+		}
+	}
+	return string(out), nil
+}
+
+// WithRegex makes every value match r: the value is ranked among the strings of
+// its length that r matches, the rank is encrypted with cycle walking (see
+// subtle.EncryptRange) and unranked again, so the token matches r and has the
+// same length. There must be at least as many such strings as the cipher's
+// minimum domain size, give or take the bound on cycle walking steps.
+func WithRegex(r *Regex) Option {
+	return func(c *config) error {
+		if r == nil {
+			return fmt.Errorf("regex cannot be nil")
+		}
+		if err := c.setMode(modeRegex); err != nil {
+			return err
+		}
+		c.regex = r
+		return nil
+	}
+}
+
+// transformRegex tokenizes (encrypt) or detokenizes s under WithRegex.
+func (t *Tokenizer) transformRegex(s string, encrypt bool) (string, error) {
+	r := t.config.regex
+	rank, err := r.Rank(s)
+	if err != nil {
+		return "", err
+	}
+	n := utf8.RuneCountInString(s)
+	last := new(big.Int).Sub(r.Count(n), big.NewInt(1))
+	if encrypt {
+		rank, err = subtle.EncryptRange(t.cipher, rank, big.NewInt(0), last)
+	} else {
+		rank, err = subtle.DecryptRange(t.cipher, rank, big.NewInt(0), last)
+	}
+	if err != nil {
+		return "", err
+	}
+	return r.Unrank(n, rank)
+}
+
+// atom returns the index of the atom that contains c, or -1.
+func (r *Regex) atom(c rune) int {
+	i := sort.Search(len(r.atoms), func(i int) bool { return r.atoms[i].hi >= c })
+	if i == len(r.atoms) || r.atoms[i].lo > c {
+		return -1
+	}
+	return i
+}
+
+// buildDFA runs the subset construction on prog over the atoms.
+func (r *Regex) buildDFA(prog *syntax.Prog, ranges map[int][]runeRange) error {
+	states := make(map[string]int)
+	var sets [][]int
+
+	add := func(set []int) (int, error) {
+		key := fmt.Sprint(set)
+		if q, ok := states[key]; ok {
+			return q, nil
+		}
+		if len(sets) == maxRegexStates {
+			return 0, fmt.Errorf("the DFA would have more than %d states", maxRegexStates)
+		}
+		q := len(sets)
+		states[key] = q
+		sets = append(sets, set)
+		accept := false
+		for _, pc := range set {
+			accept = accept || prog.Inst[pc].Op == syntax.InstMatch
+		}
+		r.accept = append(r.accept, accept)
+		r.next = append(r.next, nil)
+		return q, nil
+	}
+
+	if _, err := add(closure(prog, []uint32{uint32(prog.Start)})); err != nil {
+		return err
+	}
+	for q := 0; q < len(sets); q++ {
+		r.next[q] = make([]int, len(r.atoms))
+		for a, atom := range r.atoms {
+			var outs []uint32
+			for _, pc := range sets[q] {
+				if inRanges(ranges[pc], atom.lo) {
+					outs = append(outs, prog.Inst[pc].Out)
+				}
+			}
+			r.next[q][a] = -1
+			if len(outs) == 0 {
+				continue
+			}
+			p, err := add(closure(prog, outs))
+			if err != nil {
+				return err
+			}
+			r.next[q][a] = p
+		}
+	}
+	return nil
+}
+
+// countStrings fills counts for every state and length up to maxLength.
+func (r *Regex) countStrings() {
+	r.counts = make([][]*big.Int, len(r.next))
+	for q := range r.counts {
+		r.counts[q] = make([]*big.Int, r.maxLength+1)
+		r.counts[q][0] = new(big.Int)
+		if r.accept[q] {
+			r.counts[q][0].SetInt64(1)
+		}
+	}
+	term := new(big.Int)
+	for n := 1; n <= r.maxLength; n++ {
+		for q := range r.counts {
+			sum := new(big.Int)
+			for a, p := range r.next[q] {
+				if p >= 0 {
+					term.SetInt64(int64(r.atoms[a].hi - r.atoms[a].lo + 1))
+					sum.Add(sum, term.Mul(term, r.counts[p][n-1]))
+				}
+			}
+			r.counts[q][n] = sum
+		}
+	}
+}
+
+// closure returns the sorted rune and match instructions reachable from pcs
+// without consuming input.
+func closure(prog *syntax.Prog, pcs []uint32) []int {
+	seen := make(map[uint32]bool)
+	var set []int
+	var visit func(pc uint32)
+	visit = func(pc uint32) {
+		if seen[pc] {
+			return
+		}
+		seen[pc] = true
+		inst := &prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			visit(inst.Out)
+			visit(inst.Arg)
+		case syntax.InstCapture, syntax.InstNop, syntax.InstEmptyWidth:
+			visit(inst.Out)
+		case syntax.InstRune, syntax.InstRune1, syntax.InstMatch:
+			set = append(set, int(pc))
+		}
+	}
+	for _, pc := range pcs {
+		visit(pc)
+	}
+	sort.Ints(set)
+	return set
+}
+
+// instRanges returns the runes a rune instruction matches, including the case
+// folds of a single case-insensitive rune.
+func instRanges(inst *syntax.Inst) []runeRange {
+	if len(inst.Rune) == 1 {
+		c := inst.Rune[0]
+		ranges := []runeRange{{c, c}}
+		if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 {
+			for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+				ranges = append(ranges, runeRange{f, f})
+			}
+		}
+		return ranges
+	}
+	ranges := make([]runeRange, 0, len(inst.Rune)/2)
+	for i := 0; i+1 < len(inst.Rune); i += 2 {
+		ranges = append(ranges, runeRange{inst.Rune[i], inst.Rune[i+1]})
+	}
+	return ranges
+}
+
+// inRanges reports whether c is in one of ranges.
+func inRanges(ranges []runeRange, c rune) bool {
+	for _, rr := range ranges {
+		if c >= rr.lo && c <= rr.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionRanges splits the runes covered by ranges into the coarsest atoms
+// that are each inside or outside every range, leaving out surrogates.
+func partitionRanges(ranges map[int][]runeRange) []runeRange {
+	bounds := map[rune]bool{0xD800: true, 0xE000: true}
+	for _, rs := range ranges {
+		for _, rr := range rs {
+			bounds[rr.lo] = true
+			bounds[rr.hi+1] = true
+		}
+	}
+	points := make([]rune, 0, len(bounds))
+	for p := range bounds {
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+
+	var atoms []runeRange
+	for i := 0; i+1 < len(points); i++ {
+		atom := runeRange{points[i], points[i+1] - 1}
+		if atom.lo >= 0xD800 && atom.hi < 0xE000 {
+			continue
+		}
+		for _, rs := range ranges {
+			if inRanges(rs, atom.lo) {
+				atoms = append(atoms, atom)
+				break
+			}
+		}
+	}
+	return atoms
+}
+
+// stripAnchors removes a leading ^ and trailing $ from re, since values are
+// always matched whole.
+func stripAnchors(re *syntax.Regexp) *syntax.Regexp {
+	isBegin := func(re *syntax.Regexp) bool { return re.Op == syntax.OpBeginText || re.Op == syntax.OpBeginLine }
+	isEnd := func(re *syntax.Regexp) bool { return re.Op == syntax.OpEndText || re.Op == syntax.OpEndLine }
+	switch {
+	case isBegin(re) || isEnd(re):
+		return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	case re.Op == syntax.OpConcat && len(re.Sub) > 0:
+		subs := re.Sub
+		if isBegin(subs[0]) {
+			subs = subs[1:]
+		}
+		if len(subs) > 0 && isEnd(subs[len(subs)-1]) {
+			subs = subs[:len(subs)-1]
+		}
+		stripped := *re
+		stripped.Sub = subs
+		if len(subs) == 0 {
+			return &syntax.Regexp{Op: syntax.OpEmptyMatch}
+		}
+		return &stripped
+	}
+	return re
+}
+
+// checkRegular returns an error if re uses a construct that WithRegex cannot
+// tokenize over.
+func checkRegular(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return fmt.Errorf("'.' matches all of Unicode; use an explicit character class")
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return fmt.Errorf("anchors and word boundaries are only allowed at the start and end")
+	}
+	for _, sub := range re.Sub {
+		if err := checkRegular(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
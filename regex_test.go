@@ -0,0 +1,139 @@
+package fpe
+
+import (
+	"math/big"
+	"regexp"
+	"testing"
+)
+
+// TestRegexRankUnrank verifies that Rank and Unrank are inverse bijections
+// that follow rune order, by enumerating small languages.
+func TestRegexRankUnrank(t *testing.T) {
+	testCases := []struct {
+		expr   string
+		length int
+		count  int64
+	}{
+		{`[a-c]{2}`, 2, 9},
+		{`a|bc?|[x-z]d`, 2, 4},
+		{`(?i)ab`, 2, 4},
+		{`[0-9]*`, 3, 1000},
+		{`\d[A-HJ-NP-Z]`, 2, 240},
+		{`[αβγ]x`, 2, 3},
+	}
+
+	for _, tc := range testCases {
+		r, err := CompileRegex(tc.expr, 8)
+		if err != nil {
+			t.Fatalf("CompileRegex(%s) failed: %v", tc.expr, err)
+		}
+		if got := r.Count(tc.length); got.Int64() != tc.count {
+			t.Errorf("%s: Count(%d) = %s, want %d", tc.expr, tc.length, got, tc.count)
+		}
+
+		std := regexp.MustCompile(`^(?:` + tc.expr + `)$`)
+		previous := ""
+		for i := int64(0); i < tc.count; i++ {
+			s, err := r.Unrank(tc.length, big.NewInt(i))
+			if err != nil {
+				t.Fatalf("%s: Unrank(%d) failed: %v", tc.expr, i, err)
+			}
+			if !std.MatchString(s) || !r.Match(s) {
+				t.Errorf("%s: Unrank(%d) = %q does not match", tc.expr, i, s)
+			}
+			if i > 0 && s <= previous {
+				t.Errorf("%s: Unrank(%d) = %q is not after %q", tc.expr, i, s, previous)
+			}
+			previous = s
+			rank, err := r.Rank(s)
+			if err != nil || rank.Int64() != i {
+				t.Errorf("%s: Rank(%q) = %v, %v; want %d", tc.expr, s, rank, err, i)
+			}
+		}
+		if _, err := r.Unrank(tc.length, big.NewInt(tc.count)); err == nil {
+			t.Errorf("%s: expected Unrank to reject rank %d", tc.expr, tc.count)
+		}
+	}
+}
+
+// TestRegexTokenization verifies that tokens match the expression, keep the
+// length of the value and round-trip, with both FF1 and FF3-1.
+func TestRegexTokenization(t *testing.T) {
+	testCases := []struct {
+		expr   string
+		values []string
+	}{
+		{`[A-Z]{2}\d{6}[A-HJ-NP-Z]`, []string{"AB123456C", "ZZ000000Z"}},
+		{`^[a-z]{3,8}(-[a-z]{2,4})?$`, []string{"alpha", "beta-cd", "gammadel-xyz"}},
+		{`(?:\d{3}-){2}\d{4}|\d{10}`, []string{"555-123-4567", "5551234567"}},
+		{`[ÄÖÜäöüß]{8}`, []string{"ÄÖÜäöüßß"}},
+	}
+
+	tokenizers := map[string]func(opts ...Option) (*Tokenizer, error){
+		"FF1": func(opts ...Option) (*Tokenizer, error) {
+			return NewFF1Tokenizer(testKey, []byte("regex"), opts...)
+		},
+		"FF3-1": func(opts ...Option) (*Tokenizer, error) {
+			return NewFF31Tokenizer(testKey, []byte("tweak77"), opts...)
+		},
+	}
+	for name, newTokenizer := range tokenizers {
+		t.Run(name, func(t *testing.T) {
+			for _, tc := range testCases {
+				r := MustCompileRegex(tc.expr, 16)
+				tokenizer, err := newTokenizer(WithRegex(r))
+				if err != nil {
+					t.Fatalf("Failed to create tokenizer: %v", err)
+				}
+
+				for _, value := range tc.values {
+					tokenized, err := tokenizer.Tokenize(value)
+					if err != nil {
+						t.Fatalf("Tokenize(%s) failed: %v", value, err)
+					}
+					if tokenized == value || len([]rune(tokenized)) != len([]rune(value)) || !r.Match(tokenized) {
+						t.Errorf("%s: bad token for %s: %s", tc.expr, value, tokenized)
+					}
+					detokenized, err := tokenizer.Detokenize(tokenized)
+					if err != nil {
+						t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+					}
+					if detokenized != value {
+						t.Errorf("Round-trip failed: %s -> %s -> %s", value, tokenized, detokenized)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRegexValidation verifies that unsupported expressions and values that do
+// not match are rejected.
+func TestRegexValidation(t *testing.T) {
+	for _, expr := range []string{`[a-z`, `a.b`, `a\bb`, `a^b`, `(?s).*`} {
+		if _, err := CompileRegex(expr, 8); err == nil {
+			t.Errorf("Expected CompileRegex(%q) to fail", expr)
+		}
+	}
+	if _, err := CompileRegex(`\d+`, 0); err == nil {
+		t.Error("Expected a non-positive maximum length to be rejected")
+	}
+
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithRegex(MustCompileRegex(`[A-Z]{2}\d{6}`, 8)))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	for _, value := range []string{"", "AB12345", "ab123456", "AB1234567", "A1234567"} {
+		if _, err := tokenizer.Tokenize(value); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", value)
+		}
+	}
+
+	small, err := NewFF1Tokenizer(testKey, nil, WithRegex(MustCompileRegex(`x|yz`, 2)))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := small.Tokenize("x"); err == nil {
+		t.Error("Expected an error for a language too small to encrypt")
+	}
+}
@@ -0,0 +1,124 @@
+package fpe
+
+import (
+	"testing"
+)
+
+// TestRevealPrefixSuffix verifies that revealed characters stay in the clear,
+// format characters are not counted, and the middle round-trips.
+func TestRevealPrefixSuffix(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []Option
+		plaintext string
+		prefix    string
+		suffix    string
+	}{
+		{"PANBIN6Last4", []Option{WithAlphabet(AlphabetNumeric), WithRevealPrefix(6), WithRevealSuffix(4)}, "4532-1234-5678-9010", "4532-12", "9010"},
+		{"PANBIN8Last4", []Option{WithAlphabet(AlphabetNumeric), WithRevealPrefix(8), WithRevealSuffix(4)}, "4532123456789010123", "45321234", "0123"},
+		{"SSNLast4", []Option{WithAlphabet(AlphabetNumeric), WithRevealSuffix(4)}, "123-45-6789", "", "6789"},
+		{"Classes", []Option{WithClassPreservation(), WithRevealPrefix(2)}, "AB-1234-cd", "AB", ""},
+		{"Format", []Option{WithFormat(MustParseFormat("ID-AAA-9999")), WithRevealPrefix(1), WithRevealSuffix(1)}, "ID-ABC-1234", "ID-A", "4"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := NewFF1Tokenizer(testKey, []byte("tweak"), tc.opts...)
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+
+			tokenized, err := tokenizer.Tokenize(tc.plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if len(tokenized) != len(tc.plaintext) {
+				t.Fatalf("Length not preserved: %q -> %q", tc.plaintext, tokenized)
+			}
+			if tokenized[:len(tc.prefix)] != tc.prefix {
+				t.Errorf("Prefix not revealed: %s -> %s", tc.plaintext, tokenized)
+			}
+			if tokenized[len(tokenized)-len(tc.suffix):] != tc.suffix {
+				t.Errorf("Suffix not revealed: %s -> %s", tc.plaintext, tokenized)
+			}
+			if tokenized == tc.plaintext {
+				t.Errorf("Middle was not encrypted: %s", tokenized)
+			}
+
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != tc.plaintext {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", tc.plaintext, tokenized, detokenized)
+			}
+		})
+	}
+}
+
+// TestRevealBindsMiddleToClearCharacters verifies that the revealed characters
+// are part of the tweak: the same middle tokenizes differently under a
+// different prefix or suffix.
+func TestRevealBindsMiddleToClearCharacters(t *testing.T) {
+	for _, newTokenizer := range []func(opts ...Option) (*Tokenizer, error){
+		func(opts ...Option) (*Tokenizer, error) { return NewFF1Tokenizer(testKey, []byte("tweak"), opts...) },
+		func(opts ...Option) (*Tokenizer, error) { return NewFF31Tokenizer(testKey, []byte("tweak-7"), opts...) },
+	} {
+		tokenizer, err := newTokenizer(WithAlphabet(AlphabetNumeric), WithRevealPrefix(6), WithRevealSuffix(4))
+		if err != nil {
+			t.Fatalf("Failed to create tokenizer: %v", err)
+		}
+
+		a, err := tokenizer.Tokenize("4532121234567890")
+		if err != nil {
+			t.Fatalf("Tokenize failed: %v", err)
+		}
+		b, err := tokenizer.Tokenize("5500001234567890")
+		if err != nil {
+			t.Fatalf("Tokenize failed: %v", err)
+		}
+		c, err := tokenizer.Tokenize("4532121234561111")
+		if err != nil {
+			t.Fatalf("Tokenize failed: %v", err)
+		}
+		if a[6:12] == b[6:12] || a[6:12] == c[6:12] {
+			t.Errorf("Middle not bound to revealed characters: %s, %s, %s", a, b, c)
+		}
+
+		for _, token := range []string{a, b, c} {
+			if _, err := tokenizer.Detokenize(token); err != nil {
+				t.Errorf("Detokenize(%s) failed: %v", token, err)
+			}
+		}
+	}
+}
+
+// TestRevealRejectsSmallMiddle verifies that revealing too much is rejected.
+func TestRevealRejectsSmallMiddle(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithAlphabet(AlphabetNumeric), WithRevealPrefix(6), WithRevealSuffix(4))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	// 12 digits leave 2 hidden digits: 100 values, below FF1's minimum of 1000
+	if _, err := tokenizer.Tokenize("4532-1234-5678"); err == nil {
+		t.Error("Expected an error for a middle below the minimum domain size")
+	}
+	// Fewer data characters than revealed
+	if _, err := tokenizer.Tokenize("4532-1234"); err == nil {
+		t.Error("Expected an error for a value shorter than the revealed characters")
+	}
+
+	// FF3-1 needs 6 hidden digits
+	ff31, err := NewFF31Tokenizer(testKey, []byte("tweak-7"), WithAlphabet(AlphabetNumeric), WithRevealSuffix(4))
+	if err != nil {
+		t.Fatalf("NewFF31Tokenizer failed: %v", err)
+	}
+	if _, err := ff31.Tokenize("123-45-6789"); err == nil {
+		t.Error("Expected an error for a middle below the FF3-1 minimum domain size")
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithRevealPrefix(-1)); err == nil {
+		t.Error("Expected an error for a negative reveal count")
+	}
+}
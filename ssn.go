@@ -0,0 +1,121 @@
+package fpe
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// Sizes of the SSN fields that the SSA can issue: areas 001-899 except 666,
+// groups 01-99 and serials 0001-9999.
+const (
+	ssnAreas   = 898
+	ssnGroups  = 99
+	ssnSerials = 9999
+
+	// ssnAreas9xx is the number of never-issued areas 900-999.
+	ssnAreas9xx = 100
+)
+
+// WithSSN makes the Tokenizer treat every value as a US Social Security number,
+// written as "123-45-6789", "123 45 6789" or "123456789", and map it to another
+// SSN that satisfies the SSA validity rules: the area is not 000, 666 or
+// 900-999, the group is not 00 and the serial is not 0000. Values that break
+// these rules are rejected. The token keeps the separators of the value.
+//
+// Use WithSSNArea9xx to make tokens recognizable as tokens instead.
+func WithSSN() Option {
+	return func(c *config) error {
+		return c.setMode(modeSSN)
+	}
+}
+
+// WithSSNArea9xx makes WithSSN put every token in the never-issued areas
+// 900-999, so that tokens can never be mistaken for issued SSNs. The group is
+// still not 00 and the serial not 0000.
+//
+// The mapping is one-way: the 9xx areas hold about 99 million numbers, far
+// fewer than the 889 million valid SSNs, so about nine SSNs share each token
+// and Detokenize always fails. Use it where tokens only need to be stable,
+// such as for joins and analytics, and expect rare collisions.
+func WithSSNArea9xx() Option {
+	return func(c *config) error {
+		c.ssnArea9xx = true
+		return nil
+	}
+}
+
+// transformSSN tokenizes (encrypt) or detokenizes s under WithSSN.
+//
+// The valid SSNs are numbered in order, the number of s is encrypted with
+// subtle.EncryptRange, and the SSN with the resulting number is returned.
+// Under WithSSNArea9xx the number is reduced modulo the size of the 9xx areas
+// and numbers an SSN of those areas instead.
+func (t *Tokenizer) transformSSN(s string, encrypt bool) (string, error) {
+	if !encrypt && t.config.ssnArea9xx {
+		return "", fmt.Errorf("tokens of WithSSNArea9xx cannot be detokenized: the mapping is one-way")
+	}
+	out := []rune(s)
+	var positions []int
+	switch {
+	case len(out) == 9:
+		positions = []int{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	case len(out) == 11 && (out[3] == '-' || out[3] == ' ') && out[6] == out[3]:
+		positions = []int{0, 1, 2, 4, 5, 7, 8, 9, 10}
+	default:
+		return "", fmt.Errorf("value %q is not an SSN: expected 123-45-6789, 123 45 6789 or 123456789", s)
+	}
+
+	value := 0
+	for _, p := range positions {
+		if out[p] < '0' || out[p] > '9' {
+			return "", fmt.Errorf("SSN %q contains non-digit %q", s, out[p])
+		}
+		value = value*10 + int(out[p]-'0')
+	}
+	area, group, serial := value/1000000, value/10000%100, value%10000
+	if area == 0 || area == 666 || area >= 900 || group == 0 || serial == 0 {
+		return "", fmt.Errorf("SSN %q is not valid: area cannot be 000, 666 or 900-999, group cannot be 00 and serial cannot be 0000", s)
+	}
+
+	// Skip area 666 when numbering
+	areaIndex := area - 1
+	if area > 666 {
+		areaIndex--
+	}
+	rank := (int64(areaIndex)*ssnGroups+int64(group-1))*ssnSerials + int64(serial-1)
+
+	last := big.NewInt(ssnAreas*ssnGroups*ssnSerials - 1)
+	var result *big.Int
+	var err error
+	if encrypt {
+		result, err = subtle.EncryptRange(t.cipher, big.NewInt(rank), big.NewInt(0), last)
+	} else {
+		result, err = subtle.DecryptRange(t.cipher, big.NewInt(rank), big.NewInt(0), last)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rank = result.Int64()
+	if t.config.ssnArea9xx {
+		rank %= ssnAreas9xx * ssnGroups * ssnSerials
+	}
+	areaIndex, group, serial = int(rank/(ssnGroups*ssnSerials)), int(rank/ssnSerials%ssnGroups)+1, int(rank%ssnSerials)+1
+	switch {
+	case t.config.ssnArea9xx:
+		area = 900 + areaIndex
+	case areaIndex+1 >= 666:
+		area = areaIndex + 2
+	default:
+		area = areaIndex + 1
+	}
+
+	value = area*1000000 + group*10000 + serial
+	for i := len(positions) - 1; i >= 0; i-- {
+		out[positions[i]] = rune('0' + value%10)
+		value /= 10
+	}
+	return string(out), nil
+}
@@ -0,0 +1,128 @@
+package fpe
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSSNTokenization verifies that tokens are valid SSNs in the same layout
+// and that they round-trip, with both FF1 and FF3-1.
+func TestSSNTokenization(t *testing.T) {
+	var ssns []string
+	for i := 0; i < 200; i++ {
+		area := 1 + i*37%899
+		if area == 666 {
+			area = 665
+		}
+		ssn := fmt.Sprintf("%03d-%02d-%04d", area, 1+i*13%99, 1+i*7919%9999)
+		switch i % 3 {
+		case 1:
+			ssn = ssn[:3] + ssn[4:6] + ssn[7:]
+		case 2:
+			ssn = ssn[:3] + " " + ssn[4:6] + " " + ssn[7:]
+		}
+		ssns = append(ssns, ssn)
+	}
+	ssns = append(ssns, "001-01-0001", "899-99-9999", "665-99-9999", "667-01-0001")
+
+	tokenizers := map[string]func() (*Tokenizer, error){
+		"FF1": func() (*Tokenizer, error) {
+			return NewFF1Tokenizer(testKey, []byte("ssn"), WithSSN())
+		},
+		"FF3-1": func() (*Tokenizer, error) {
+			return NewFF31Tokenizer(testKey, []byte("tweak77"), WithSSN())
+		},
+	}
+	for name, newTokenizer := range tokenizers {
+		t.Run(name, func(t *testing.T) {
+			tokenizer, err := newTokenizer()
+			if err != nil {
+				t.Fatalf("Failed to create tokenizer: %v", err)
+			}
+
+			for _, ssn := range ssns {
+				tokenized, err := tokenizer.Tokenize(ssn)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", ssn, err)
+				}
+				if len(tokenized) != len(ssn) || len(ssn) == 11 && (tokenized[3] != ssn[3] || tokenized[6] != ssn[6]) {
+					t.Errorf("Layout not preserved: %s -> %s", ssn, tokenized)
+				}
+				// A token that is not a valid SSN cannot be detokenized
+				detokenized, err := tokenizer.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != ssn {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", ssn, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestSSNValidation verifies that SSNs breaking the SSA rules are rejected.
+func TestSSNValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithSSN())
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for _, ssn := range []string{
+		"000-45-6789",
+		"666-45-6789",
+		"900-45-6789",
+		"999-45-6789",
+		"123-00-6789",
+		"123-45-0000",
+		"123-45-678",
+		"123-456-789",
+		"123-45 6789",
+		"12a-45-6789",
+		"1234567890",
+	} {
+		if _, err := tokenizer.Tokenize(ssn); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", ssn)
+		}
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithSSN(), WithRevealSuffix(4)); err == nil {
+		t.Error("Expected WithSSN and reveal options to be exclusive")
+	}
+}
+
+// TestSSNArea9xx verifies that WithSSNArea9xx puts tokens in the 9xx areas
+// with a valid group and serial, and that they cannot be detokenized.
+func TestSSNArea9xx(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, []byte("ssn"), WithSSN(), WithSSNArea9xx())
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for i := 0; i < 200; i++ {
+		area := 1 + i*37%899
+		if area == 666 {
+			area = 665
+		}
+		ssn := fmt.Sprintf("%03d-%02d-%04d", area, 1+i*13%99, 1+i*7919%9999)
+		tokenized, err := tokenizer.Tokenize(ssn)
+		if err != nil {
+			t.Fatalf("Tokenize(%s) failed: %v", ssn, err)
+		}
+		if len(tokenized) != 11 || tokenized[0] != '9' || tokenized[3] != '-' || tokenized[6] != '-' ||
+			tokenized[4:6] == "00" || tokenized[7:] == "0000" {
+			t.Errorf("Expected a 9xx SSN with a valid group and serial for %s, got %s", ssn, tokenized)
+		}
+		again, err := tokenizer.Tokenize(ssn)
+		if err != nil || again != tokenized {
+			t.Errorf("Tokenize(%s) is not deterministic: %s, %s (%v)", ssn, tokenized, again, err)
+		}
+		if _, err := tokenizer.Detokenize(tokenized); err == nil {
+			t.Errorf("Expected Detokenize(%s) to fail", tokenized)
+		}
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithSSNArea9xx()); err == nil {
+		t.Error("Expected WithSSNArea9xx to require WithSSN")
+	}
+}
@@ -0,0 +1,154 @@
+package subtle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// byteAlphabet is the radix-256 alphabet bytes are encrypted over: the runes
+// U+0000 to U+00FF, one per byte value. Only its rune count matters.
+var byteAlphabet = func() string {
+	var b strings.Builder
+	for r := rune(0); r < 256; r++ {
+		b.WriteRune(r)
+	}
+	return b.String()
+}()
+
+// EncryptBytes encrypts data, an opaque byte string such as a binary ID, to a
+// byte string of the same length using c over radix 256: every byte is one
+// numeral. data must meet the cipher's minimum domain (2 bytes for FF1,
+// 3 bytes for FF3-1) and, for FF3-1, its maximum length (24 bytes).
+func EncryptBytes(c Cipher, data []byte) ([]byte, error) {
+	return cipherBytes(c, data, true)
+}
+
+// DecryptBytes inverts EncryptBytes.
+func DecryptBytes(c Cipher, data []byte) ([]byte, error) {
+	return cipherBytes(c, data, false)
+}
+
+// EncryptBits encrypts the first bitLen bits of data, most significant bit of
+// data[0] first, using c over radix 2, for values whose length is not a whole
+// number of bytes. data must be exactly (bitLen+7)/8 bytes long and its unused
+// trailing bits must be zero; they are zero in the result as well.
+func EncryptBits(c Cipher, data []byte, bitLen int) ([]byte, error) {
+	return cipherBits(c, data, bitLen, true)
+}
+
+// DecryptBits inverts EncryptBits.
+func DecryptBits(c Cipher, data []byte, bitLen int) ([]byte, error) {
+	return cipherBits(c, data, bitLen, false)
+}
+
+// EncryptBytes encrypts data to a byte string of the same length.
+// See the EncryptBytes function.
+func (f *FF1) EncryptBytes(data []byte) ([]byte, error) {
+	if f.mode == ModeLegacy {
+		return nil, fmt.Errorf("EncryptBytes is not available in %v mode", f.mode)
+	}
+	return EncryptBytes(f, data)
+}
+
+// DecryptBytes inverts EncryptBytes.
+func (f *FF1) DecryptBytes(data []byte) ([]byte, error) {
+	if f.mode == ModeLegacy {
+		return nil, fmt.Errorf("DecryptBytes is not available in %v mode", f.mode)
+	}
+	return DecryptBytes(f, data)
+}
+
+// EncryptBits encrypts the first bitLen bits of data. See the EncryptBits function.
+func (f *FF1) EncryptBits(data []byte, bitLen int) ([]byte, error) {
+	if f.mode == ModeLegacy {
+		return nil, fmt.Errorf("EncryptBits is not available in %v mode", f.mode)
+	}
+	return EncryptBits(f, data, bitLen)
+}
+
+// DecryptBits inverts EncryptBits.
+func (f *FF1) DecryptBits(data []byte, bitLen int) ([]byte, error) {
+	if f.mode == ModeLegacy {
+		return nil, fmt.Errorf("DecryptBits is not available in %v mode", f.mode)
+	}
+	return DecryptBits(f, data, bitLen)
+}
+
+// EncryptBytes encrypts data to a byte string of the same length.
+// See the EncryptBytes function.
+func (f *FF31) EncryptBytes(data []byte) ([]byte, error) {
+	return EncryptBytes(f, data)
+}
+
+// DecryptBytes inverts EncryptBytes.
+func (f *FF31) DecryptBytes(data []byte) ([]byte, error) {
+	return DecryptBytes(f, data)
+}
+
+// EncryptBits encrypts the first bitLen bits of data. See the EncryptBits function.
+func (f *FF31) EncryptBits(data []byte, bitLen int) ([]byte, error) {
+	return EncryptBits(f, data, bitLen)
+}
+
+// DecryptBits inverts EncryptBits.
+func (f *FF31) DecryptBits(data []byte, bitLen int) ([]byte, error) {
+	return DecryptBits(f, data, bitLen)
+}
+
+// cipherBytes implements EncryptBytes and DecryptBytes.
+func cipherBytes(c Cipher, data []byte, encrypt bool) ([]byte, error) {
+	numerals := make([]uint16, len(data))
+	for i, b := range data {
+		numerals[i] = uint16(b)
+	}
+
+	var err error
+	if encrypt {
+		numerals, err = c.Encrypt(numerals, byteAlphabet)
+	} else {
+		numerals, err = c.Decrypt(numerals, byteAlphabet)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(numerals))
+	for i, x := range numerals {
+		out[i] = byte(x)
+	}
+	return out, nil
+}
+
+// cipherBits implements EncryptBits and DecryptBits.
+func cipherBits(c Cipher, data []byte, bitLen int, encrypt bool) ([]byte, error) {
+	if bitLen < 0 {
+		return nil, fmt.Errorf("invalid bit length: %d", bitLen)
+	}
+	if want := (bitLen + 7) / 8; len(data) != want {
+		return nil, fmt.Errorf("%d bits must be held in %d bytes, got %d", bitLen, want, len(data))
+	}
+	if rest := bitLen % 8; rest != 0 && data[len(data)-1]&(0xff>>rest) != 0 {
+		return nil, fmt.Errorf("unused trailing bits of the last byte must be zero")
+	}
+
+	numerals := make([]uint16, bitLen)
+	for i := range numerals {
+		numerals[i] = uint16(data[i/8]>>(7-i%8)) & 1
+	}
+
+	var err error
+	if encrypt {
+		numerals, err = c.Encrypt(numerals, binaryAlphabet)
+	} else {
+		numerals, err = c.Decrypt(numerals, binaryAlphabet)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(data))
+	for i, b := range numerals {
+		out[i/8] |= byte(b) << (7 - i%8)
+	}
+	return out, nil
+}
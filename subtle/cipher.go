@@ -0,0 +1,42 @@
+package subtle
+
+// Cipher is a format-preserving block cipher over numeral strings.
+// Numerals are indices into an alphabet; the radix is the number of
+// characters (runes) in the alphabet, so alphabets may contain non-ASCII runes.
+// It is implemented by FF1 and FF31.
+type Cipher interface {
+	// Encrypt enciphers plaintext numerals over the radix of alphabet.
+	Encrypt(plaintext []uint16, alphabet string) ([]uint16, error)
+
+	// Decrypt deciphers ciphertext numerals over the radix of alphabet.
+	Decrypt(ciphertext []uint16, alphabet string) ([]uint16, error)
+}
+
+// TweakableCipher is a Cipher that can also run with a tweak supplied per call
+// in place of the tweak it was created with.
+// It is implemented by FF1 and FF31.
+type TweakableCipher interface {
+	Cipher
+
+	// EncryptWithTweak is Encrypt using tweak instead of the cipher's own tweak.
+	EncryptWithTweak(plaintext []uint16, alphabet string, tweak []byte) ([]uint16, error)
+
+	// DecryptWithTweak is Decrypt using tweak instead of the cipher's own tweak.
+	DecryptWithTweak(ciphertext []uint16, alphabet string, tweak []byte) ([]uint16, error)
+
+	// Tweak returns the tweak the cipher was created with.
+	Tweak() []byte
+
+	// TweakSize returns the tweak length in bytes the cipher requires, or 0 if
+	// tweaks of any length are accepted.
+	TweakSize() int
+
+	// MinDomainSize returns the smallest number of possible values (radix^length)
+	// the cipher accepts.
+	MinDomainSize() int
+}
+
+var (
+	_ TweakableCipher = (*FF1)(nil)
+	_ TweakableCipher = (*FF31)(nil)
+)
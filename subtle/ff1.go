@@ -5,8 +5,58 @@ package subtle
 
 import (
 	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
 	"fmt"
 	"math/big"
+	"unicode/utf8"
+)
+
+// Mode selects the round function used by an FF1 instance.
+type Mode int
+
+const (
+	// ModeNIST is FF1 exactly as specified in NIST SP 800-38G. Its output is
+	// interoperable with other conforming FF1 implementations. This is the default.
+	ModeNIST Mode = iota
+
+	// ModeLegacy reproduces the round function used by earlier releases of this
+	// package, which did not conform to NIST SP 800-38G. It exists only so that
+	// tokens issued by those releases can still be detokenized and must not be
+	// used to issue new tokens.
+	ModeLegacy
+)
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeNIST:
+		return "NIST"
+	case ModeLegacy:
+		return "Legacy"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+const (
+	// FF1MaxTweakSize is the longest tweak accepted by FF1 in ModeNIST: 65536
+	// bytes (64 KiB), the maxTlen of this implementation. SP 800-38G leaves
+	// maxTlen to the implementation; every Feistel round runs the whole tweak
+	// through the CBC-MAC, so the limit bounds the work and memory of a single
+	// call while leaving room for any realistic tweak.
+	FF1MaxTweakSize = 1 << 16
+
+	// feistelRounds is the number of Feistel rounds used by FF1.
+	feistelRounds = 10
+
+	// maxInputLength bounds the input length to prevent resource exhaustion.
+	// NIST FF1 allows up to 2^32 numerals, but we set a reasonable limit.
+	maxInputLength = 100000 // 100k characters
+
+	// minDomainSize is the smallest radix^n accepted for security.
+	// This prevents using FF1 on very small domains which are not secure.
+	minDomainSize = 1000
 )
 
 // FF1 implements the core NIST SP 800-38G FF1 algorithm using raw keys.
@@ -14,20 +64,69 @@ import (
 type FF1 struct {
 	key   []byte
 	tweak []byte
+	mode  Mode
+	block cipher.Block
 }
 
 // NewFF1 creates a new FF1 instance with the given raw key and tweak.
-// The key should be at least 16 bytes (AES-128) or 32 bytes (AES-256).
+// The key must be 16, 24, or 32 bytes (AES-128, AES-192, or AES-256).
 // The tweak is a public, non-secret value that ensures different ciphertexts
 // for the same plaintext when the tweak changes.
 func NewFF1(key, tweak []byte) (*FF1, error) {
+	return NewFF1WithMode(key, tweak, ModeNIST)
+}
+
+// NewFF1Legacy creates an FF1 instance that uses the pre-NIST round function
+// (see ModeLegacy). Use it only to detokenize values issued by earlier releases.
+func NewFF1Legacy(key, tweak []byte) (*FF1, error) {
+	return NewFF1WithMode(key, tweak, ModeLegacy)
+}
+
+// NewFF1WithMode creates a new FF1 instance using the given mode.
+// ModeNIST requires a 16, 24, or 32 byte key. ModeLegacy keeps the historical
+// behavior of accepting any key of at least 16 bytes.
+func NewFF1WithMode(key, tweak []byte, mode Mode) (*FF1, error) {
 	if len(key) < 16 {
 		return nil, fmt.Errorf("key must be at least 16 bytes, got %d", len(key))
 	}
-	return &FF1{
-		key:   key,
-		tweak: tweak,
-	}, nil
+
+	switch mode {
+	case ModeNIST:
+		if err := validateFF1Tweak(tweak); err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key size: %d bytes (must be 16, 24, or 32)", len(key))
+		}
+		return &FF1{
+			key:   key,
+			tweak: tweak,
+			mode:  mode,
+			block: block,
+		}, nil
+	case ModeLegacy:
+		return &FF1{
+			key:   key,
+			tweak: tweak,
+			mode:  mode,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported FF1 mode: %v", mode)
+	}
+}
+
+// Mode returns the mode this instance was created with.
+func (f *FF1) Mode() Mode {
+	return f.mode
+}
+
+// WithMode returns a copy of f with the same key and tweak that uses mode.
+func (f *FF1) WithMode(mode Mode) (*FF1, error) {
+	if mode == f.mode {
+		return f, nil
+	}
+	return NewFF1WithMode(f.key, f.tweak, mode)
 }
 
 // Encrypt performs FF1 format-preserving encryption on numeric data.
@@ -40,88 +139,21 @@ func NewFF1(key, tweak []byte) (*FF1, error) {
 // Thread safety: This method is safe for concurrent use by multiple goroutines,
 // as it does not modify the FF1 instance state.
 func (f *FF1) Encrypt(plaintext []uint16, alphabet string) ([]uint16, error) {
-	radix := len(alphabet)
+	radix := utf8.RuneCountInString(alphabet)
 	n := len(plaintext)
 
 	if n == 0 {
 		return plaintext, nil
 	}
 
-	// Validate maximum practical input length to prevent resource exhaustion
-	// NIST FF1 doesn't specify a maximum, but we set a reasonable limit
-	const maxInputLength = 100000 // 100k characters
-	if n > maxInputLength {
-		return nil, fmt.Errorf("input too long: %d characters (maximum %d)", n, maxInputLength)
+	if err := f.validate(plaintext, radix); err != nil {
+		return nil, err
 	}
 
-	// Validate minimum domain size for security
-	// Domain size = radix^n. For security, we require domain size >= 1000
-	// This prevents using FF1 on very small domains which are not secure
-	domainSize := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(n)), nil)
-	minDomainSize := big.NewInt(1000)
-	if domainSize.Cmp(minDomainSize) < 0 {
-		return nil, fmt.Errorf("domain size too small: radix=%d, length=%d, domain_size=%s (minimum 1000 required for security)", radix, n, domainSize.String())
-	}
-
-	// Step 1: Split into left and right halves
-	// u = floor(n/2), v = ceil(n/2)
-	u := n / 2
-	v := n - u
-
-	// Step 2: Initialize A and B
-	// A = first u elements, B = last v elements
-	A := make([]uint16, u)
-	B := make([]uint16, v)
-	copy(A, plaintext[:u])
-	copy(B, plaintext[u:])
-
-	// Get properly sized key
-	aesKey := f.getAESKey()
-
-	// Number of rounds (FF1 uses 10 rounds)
-	rounds := 10
-
-	// Step 6: Feistel rounds
-	for i := 0; i < rounds; i++ {
-		// Current sizes: A has size len(A), B has size len(B)
-		// F function: compute on B, output should have size len(A)
-		currentU := len(A)
-		currentV := len(B)
-		C := f.feistelFunction(B, i, currentU, currentV, n, radix, aesKey)
-
-		// Ensure C has exactly len(A) elements
-		if len(C) != len(A) {
-			// Pad or truncate C to match A's length
-			newC := make([]uint16, len(A))
-			for j := 0; j < len(A); j++ {
-				if j < len(C) {
-					newC[j] = C[j]
-				} else {
-					newC[j] = 0
-				}
-			}
-			C = newC
-		}
-
-		// Feistel round:
-		// A_{i+1} = B_i
-		// B_{i+1} = (A_i + C) mod radix (element-wise)
-		newB := make([]uint16, len(A))
-		for j := 0; j < len(A); j++ {
-			val := uint32(A[j]) + uint32(C[j])
-			newB[j] = uint16(val % uint32(radix))
-		}
-
-		// Update for next round: A_{i+1} = B_i, B_{i+1} = newB
-		A, B = B, newB
+	if f.mode == ModeLegacy {
+		return f.legacyEncrypt(plaintext, radix), nil
 	}
-
-	// Step 7: Output A || B
-	result := make([]uint16, n)
-	copy(result, A)
-	copy(result[len(A):], B)
-
-	return result, nil
+	return f.cipher(plaintext, radix, true), nil
 }
 
 // Decrypt performs FF1 format-preserving decryption on numeric data.
@@ -134,202 +166,232 @@ func (f *FF1) Encrypt(plaintext []uint16, alphabet string) ([]uint16, error) {
 // Thread safety: This method is safe for concurrent use by multiple goroutines,
 // as it does not modify the FF1 instance state.
 func (f *FF1) Decrypt(ciphertext []uint16, alphabet string) ([]uint16, error) {
-	radix := len(alphabet)
+	radix := utf8.RuneCountInString(alphabet)
 	n := len(ciphertext)
 
 	if n == 0 {
 		return ciphertext, nil
 	}
 
-	// Validate maximum practical input length to prevent resource exhaustion
-	const maxInputLength = 100000 // 100k characters
-	if n > maxInputLength {
-		return nil, fmt.Errorf("input too long: %d characters (maximum %d)", n, maxInputLength)
+	if err := f.validate(ciphertext, radix); err != nil {
+		return nil, err
 	}
 
-	// Validate minimum domain size for security (same as encryption)
-	domainSize := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(n)), nil)
-	minDomainSize := big.NewInt(1000)
-	if domainSize.Cmp(minDomainSize) < 0 {
-		return nil, fmt.Errorf("domain size too small: radix=%d, length=%d, domain_size=%s (minimum 1000 required for security)", radix, n, domainSize.String())
+	if f.mode == ModeLegacy {
+		return f.legacyDecrypt(ciphertext, radix), nil
 	}
+	return f.cipher(ciphertext, radix, false), nil
+}
 
-	// Step 1: Split into left and right halves
-	u := n / 2
-	v := n - u
-
-	// Start with the final state from encryption
-	A := make([]uint16, u)
-	B := make([]uint16, v)
-	copy(A, ciphertext[:u])
-	copy(B, ciphertext[u:])
-
-	// Get properly sized key
-	aesKey := f.getAESKey()
-
-	// Number of rounds (same as encryption)
-	rounds := 10
-
-	// Decrypt by running rounds in reverse
-	for i := rounds - 1; i >= 0; i-- {
-		// Current sizes: A has size len(A), B has size len(B)
-		// F function: compute on A (which was B_i), output should have size len(B)
-		currentU := len(B) // Output size (size of B, which was A_{i+1})
-		currentV := len(A) // Input size (size of A, which was B_i)
-		C := f.feistelFunction(A, i, currentU, currentV, n, radix, aesKey)
-
-		// Ensure C has exactly len(B) elements
-		if len(C) != len(B) {
-			// Pad or truncate C to match B's length
-			newC := make([]uint16, len(B))
-			for j := 0; j < len(B); j++ {
-				if j < len(C) {
-					newC[j] = C[j]
-				} else {
-					newC[j] = 0
-				}
-			}
-			C = newC
-		}
+// EncryptWithTweak performs FF1 encryption as Encrypt does, but with tweak in
+// place of the tweak the instance was created with. In ModeNIST the tweak can
+// be at most FF1MaxTweakSize bytes.
+func (f *FF1) EncryptWithTweak(plaintext []uint16, alphabet string, tweak []byte) ([]uint16, error) {
+	return f.WithTweak(tweak).Encrypt(plaintext, alphabet)
+}
 
-		// Recover A_i: A_i = (B_{i+1} - C + radix) mod radix
-		oldA := make([]uint16, len(B))
-		for j := 0; j < len(B); j++ {
-			cIdx := j % len(C)
-			cVal := uint32(C[cIdx])
-			val := uint32(B[j]) + uint32(radix) - cVal
-			oldA[j] = uint16(val % uint32(radix))
-		}
+// DecryptWithTweak performs FF1 decryption as Decrypt does, but with tweak in
+// place of the tweak the instance was created with. In ModeNIST the tweak can
+// be at most FF1MaxTweakSize bytes.
+func (f *FF1) DecryptWithTweak(ciphertext []uint16, alphabet string, tweak []byte) ([]uint16, error) {
+	return f.WithTweak(tweak).Decrypt(ciphertext, alphabet)
+}
 
-		// Recover B_i: B_i = A_{i+1} = current A
-		oldB := make([]uint16, len(A))
-		copy(oldB, A)
+// Tweak returns the tweak the instance was created with.
+func (f *FF1) Tweak() []byte {
+	return f.tweak
+}
 
-		// Update for next iteration: A = A_i, B = B_i
-		A = oldA
-		B = oldB
-	}
+// TweakSize returns 0: FF1 accepts tweaks of any length up to FF1MaxTweakSize.
+func (f *FF1) TweakSize() int {
+	return 0
+}
 
-	// After all rounds, we have A = A_0 (u elements), B = B_0 (v elements)
-	result := make([]uint16, n)
-	copy(result, A)
-	copy(result[len(A):], B)
+// MinDomainSize returns the smallest radix^length accepted by Encrypt and Decrypt.
+func (f *FF1) MinDomainSize() int {
+	return minDomainSize
+}
 
-	return result, nil
+// WithTweak returns a copy of f that uses tweak. The copy shares the block
+// cipher, which is safe for concurrent use.
+func (f *FF1) WithTweak(tweak []byte) *FF1 {
+	g := *f
+	g.tweak = tweak
+	return &g
 }
 
-// feistelFunction implements the F function for FF1 following NIST SP 800-38G.
-// This is the core PRF used in each Feistel round.
-func (f *FF1) feistelFunction(B []uint16, roundNum, u, v, n, radix int, aesKey []byte) []uint16 {
-	m := len(B)
-	if m == 0 {
-		return make([]uint16, u)
+// validate checks the input length, the domain size and, in NIST mode, the
+// tweak length and that the radix and every numeral are within the bounds
+// required by SP 800-38G.
+func (f *FF1) validate(X []uint16, radix int) error {
+	n := len(X)
+
+	// Validate maximum practical input length to prevent resource exhaustion
+	if n > maxInputLength {
+		return fmt.Errorf("input too long: %d characters (maximum %d)", n, maxInputLength)
 	}
 
-	// Step 6.i: Build Q array
-	Q := f.buildQArray(roundNum, B, radix)
+	// Validate minimum domain size for security
+	// Domain size = radix^n. For security, we require domain size >= 1000
+	domainSize := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(n)), nil)
+	if domainSize.Cmp(big.NewInt(minDomainSize)) < 0 {
+		return fmt.Errorf("domain size too small: radix=%d, length=%d, domain_size=%s (minimum %d required for security)", radix, n, domainSize.String(), minDomainSize)
+	}
 
-	// Step 6.ii: Encrypt Q with AES to get R
-	block, err := aes.NewCipher(aesKey)
-	if err != nil {
-		// This should not happen with valid key sizes, but handle gracefully
-		return make([]uint16, u)
+	if f.mode == ModeLegacy {
+		return nil
 	}
 
-	// Q must be padded to AES block size (16 bytes)
-	blockSize := aes.BlockSize
-	qLen := len(Q)
-	paddedLen := ((qLen + blockSize - 1) / blockSize) * blockSize
-	Q_padded := make([]byte, paddedLen)
-	copy(Q_padded, Q)
-
-	// Encrypt Q_padded with AES-ECB
-	R := make([]byte, paddedLen)
-	for i := 0; i < paddedLen; i += blockSize {
-		block.Encrypt(R[i:], Q_padded[i:])
+	if err := validateFF1Tweak(f.tweak); err != nil {
+		return err
 	}
 
-	// Step 6.iii: Extract S (first d bytes of R)
-	d := (u*bitLength(radix) + 7) / 8
-	if d < 1 {
-		d = 1
+	// SP 800-38G: radix in [2..2^16] and minlen >= 2
+	if radix < 2 || radix > 1<<16 {
+		return fmt.Errorf("invalid radix: %d (must be between 2 and 65536)", radix)
 	}
-	if d > len(R) {
-		d = len(R)
+	if n < 2 {
+		return fmt.Errorf("input too short: %d characters (minimum 2)", n)
+	}
+	for i, x := range X {
+		if int(x) >= radix {
+			return fmt.Errorf("numeral %d at position %d is out of range for radix %d", x, i, radix)
+		}
 	}
-	// For small outputs, use more bytes for better distribution
-	if d < 8 && len(R) >= 8 {
-		d = 8
+
+	return nil
+}
+
+// validateFF1Tweak checks that tweak is at most FF1MaxTweakSize bytes.
+func validateFF1Tweak(tweak []byte) error {
+	if len(tweak) > FF1MaxTweakSize {
+		return fmt.Errorf("invalid tweak size: %d bytes (FF1 allows at most %d)", len(tweak), FF1MaxTweakSize)
 	}
-	S := R[:d]
+	return nil
+}
 
-	// Step 6.iv: Convert S to integer y (big-endian)
-	y := new(big.Int).SetBytes(S)
+// cipher runs the FF1 Feistel network as specified in NIST SP 800-38G,
+// Algorithm 7 (encrypt) and Algorithm 8 (decrypt).
+func (f *FF1) cipher(X []uint16, radix int, encrypt bool) []uint16 {
+	n := len(X)
+	t := len(f.tweak)
 
-	// Step 6.v: m is the output length (u)
-	// Step 6.vi: Compute c = y mod (radix^m)
+	// Steps 1-2: u = floor(n/2), v = n - u; A = X[1..u], B = X[u+1..n]
+	u := n / 2
+	v := n - u
+	A := numradixEncode(X[:u], radix)
+	B := numradixEncode(X[u:], radix)
+
+	// Step 3: b = ceil(ceil(v * log2(radix)) / 8), the byte length of NUM_radix(B)
 	radixBig := big.NewInt(int64(radix))
-	radixPowM := new(big.Int).Exp(radixBig, big.NewInt(int64(u)), nil)
-	c := new(big.Int).Mod(y, radixPowM)
+	radixPowU := new(big.Int).Exp(radixBig, big.NewInt(int64(u)), nil)
+	radixPowV := new(big.Int).Exp(radixBig, big.NewInt(int64(v)), nil)
+	b := (new(big.Int).Sub(radixPowV, big.NewInt(1)).BitLen() + 7) / 8
+
+	// Step 4: d = 4 * ceil(b/4) + 4
+	d := 4*((b+3)/4) + 4
+
+	// Step 5: P = [1]^1 || [2]^1 || [1]^1 || [radix]^3 || [10]^1 || [u mod 256]^1 || [n]^4 || [t]^4
+	P := make([]byte, aes.BlockSize)
+	P[0] = 1
+	P[1] = 2
+	P[2] = 1
+	P[3] = byte(radix >> 16)
+	P[4] = byte(radix >> 8)
+	P[5] = byte(radix)
+	P[6] = feistelRounds
+	P[7] = byte(u)
+	binary.BigEndian.PutUint32(P[8:12], uint32(n))
+	binary.BigEndian.PutUint32(P[12:16], uint32(t))
+
+	// Q = T || [0]^((-t-b-1) mod 16) || [i]^1 || [NUM_radix(B)]^b
+	pad := (16 - (t+b+1)%16) % 16
+	Q := make([]byte, t+pad+1+b)
+	copy(Q, f.tweak)
+
+	// The CBC-MAC over P does not depend on the round, so compute it once.
+	prefix := make([]byte, aes.BlockSize)
+	f.block.Encrypt(prefix, P)
+
+	S := make([]byte, ((d+aes.BlockSize-1)/aes.BlockSize)*aes.BlockSize)
+	y := new(big.Int)
+	c := new(big.Int)
+
+	for r := 0; r < feistelRounds; r++ {
+		i := r
+		if !encrypt {
+			i = feistelRounds - 1 - r
+		}
 
-	// Step 6.vii: Convert c to base-radix representation of length u
-	C := numradixDecode(c, radix, u)
+		// Step 6.i: finish Q with the round number and the numeral string that
+		// feeds the round function (B when encrypting, A when decrypting).
+		Q[t+pad] = byte(i)
+		if encrypt {
+			B.FillBytes(Q[t+pad+1:])
+		} else {
+			A.FillBytes(Q[t+pad+1:])
+		}
 
-	return C
-}
+		// Step 6.ii: R = PRF(P || Q)
+		R := f.prf(prefix, Q)
 
-// buildQArray constructs the Q array for a specific round as specified in NIST FF1.
-func (f *FF1) buildQArray(roundNum int, B []uint16, radix int) []byte {
-	// Q starts with 4 bytes of round number
-	Q := make([]byte, 0)
-	Q = append(Q, byte(roundNum))
-	Q = append(Q, byte(roundNum))
-	Q = append(Q, byte(roundNum))
-	Q = append(Q, byte(roundNum))
-
-	// Add tweak
-	Q = append(Q, f.tweak...)
-
-	// Add B array encoded using numradix
-	B_bytes := numradixToBytes(B, radix)
-	Q = append(Q, B_bytes...)
-
-	// Pad Q to AES block size (16 bytes) boundary
-	blockSize := aes.BlockSize
-	qLen := len(Q)
-	paddedLen := ((qLen + blockSize - 1) / blockSize) * blockSize
-	if paddedLen > qLen {
-		padding := make([]byte, paddedLen-qLen)
-		Q = append(Q, padding...)
-	}
+		// Step 6.iii: S = first d bytes of R || CIPH(R xor [1]^16) || CIPH(R xor [2]^16) ...
+		f.expand(S, R)
 
-	return Q
-}
+		// Step 6.iv: y = NUM(S)
+		y.SetBytes(S[:d])
 
-// getAESKey returns the AES key properly sized (16, 24, or 32 bytes).
-func (f *FF1) getAESKey() []byte {
-	keyLen := len(f.key)
+		// Step 6.v: m = u if i is even, v otherwise
+		radixPowM := radixPowU
+		if i%2 == 1 {
+			radixPowM = radixPowV
+		}
 
-	// AES supports 16, 24, or 32 byte keys
-	if keyLen == 16 || keyLen == 24 || keyLen == 32 {
-		return f.key
+		if encrypt {
+			// Steps 6.vi-6.ix: c = (NUM_radix(A) + y) mod radix^m; A = B; B = C
+			c.Add(A, y)
+			c.Mod(c, radixPowM)
+			A, B, c = B, c, A
+		} else {
+			// Inverse round: c = (NUM_radix(B) - y) mod radix^m; B = A; A = C
+			c.Sub(B, y)
+			c.Mod(c, radixPowM)
+			B, A, c = A, c, B
+		}
 	}
 
-	// If key is < 16 bytes, pad to 16
-	if keyLen < 16 {
-		padded := make([]byte, 16)
-		copy(padded, f.key)
-		return padded
-	}
+	// Step 7: return A || B
+	result := make([]uint16, n)
+	copy(result, numradixDecode(A, radix, u))
+	copy(result[u:], numradixDecode(B, radix, v))
+	return result
+}
 
-	// If key is between sizes, use the next smaller standard size
-	if keyLen < 24 {
-		return f.key[:16]
-	}
-	if keyLen < 32 {
-		return f.key[:24]
+// prf computes the CBC-MAC of Q chained from the already-encrypted P block.
+// len(Q) is always a multiple of the AES block size.
+func (f *FF1) prf(prefix, Q []byte) []byte {
+	R := make([]byte, aes.BlockSize)
+	copy(R, prefix)
+	for j := 0; j < len(Q); j += aes.BlockSize {
+		for k := 0; k < aes.BlockSize; k++ {
+			R[k] ^= Q[j+k]
+		}
+		f.block.Encrypt(R, R)
 	}
+	return R
+}
 
-	// If key is > 32 bytes, use first 32
-	return f.key[:32]
+// expand fills S with R || CIPH(R xor [1]^16) || CIPH(R xor [2]^16) || ...
+func (f *FF1) expand(S, R []byte) {
+	copy(S, R)
+	block := make([]byte, aes.BlockSize)
+	for j := 1; j*aes.BlockSize < len(S); j++ {
+		copy(block, R)
+		var counter [8]byte
+		binary.BigEndian.PutUint64(counter[:], uint64(j))
+		for k := 0; k < 8; k++ {
+			block[aes.BlockSize-8+k] ^= counter[k]
+		}
+		f.block.Encrypt(S[j*aes.BlockSize:], block)
+	}
 }
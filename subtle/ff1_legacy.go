@@ -0,0 +1,264 @@
+package subtle
+
+import (
+	"crypto/aes"
+	"math/big"
+)
+
+// This file contains the round function used by releases of this package that
+// predate NIST SP 800-38G conformance. It is not FF1: the PRF encrypts a Q-only
+// block in ECB mode without the P block or CBC-MAC chaining, so its output does
+// not match other FF1 implementations. It is kept solely so that tokens issued
+// by those releases can still be detokenized; see ModeLegacy.
+
+// legacyEncrypt runs the legacy Feistel network over plaintext.
+// Input validation is performed by the caller.
+func (f *FF1) legacyEncrypt(plaintext []uint16, radix int) []uint16 {
+	n := len(plaintext)
+
+	// Step 1: Split into left and right halves
+	// u = floor(n/2), v = ceil(n/2)
+	u := n / 2
+	v := n - u
+
+	// Step 2: Initialize A and B
+	// A = first u elements, B = last v elements
+	A := make([]uint16, u)
+	B := make([]uint16, v)
+	copy(A, plaintext[:u])
+	copy(B, plaintext[u:])
+
+	// Get properly sized key
+	aesKey := f.legacyAESKey()
+
+	// Number of rounds (FF1 uses 10 rounds)
+	rounds := 10
+
+	// Step 6: Feistel rounds
+	for i := 0; i < rounds; i++ {
+		// Current sizes: A has size len(A), B has size len(B)
+		// F function: compute on B, output should have size len(A)
+		currentU := len(A)
+		currentV := len(B)
+		C := f.legacyFeistelFunction(B, i, currentU, currentV, n, radix, aesKey)
+
+		// Ensure C has exactly len(A) elements
+		if len(C) != len(A) {
+			// Pad or truncate C to match A's length
+			newC := make([]uint16, len(A))
+			for j := 0; j < len(A); j++ {
+				if j < len(C) {
+					newC[j] = C[j]
+				} else {
+					newC[j] = 0
+				}
+			}
+			C = newC
+		}
+
+		// Feistel round:
+		// A_{i+1} = B_i
+		// B_{i+1} = (A_i + C) mod radix (element-wise)
+		newB := make([]uint16, len(A))
+		for j := 0; j < len(A); j++ {
+			val := uint32(A[j]) + uint32(C[j])
+			newB[j] = uint16(val % uint32(radix))
+		}
+
+		// Update for next round: A_{i+1} = B_i, B_{i+1} = newB
+		A, B = B, newB
+	}
+
+	// Step 7: Output A || B
+	result := make([]uint16, n)
+	copy(result, A)
+	copy(result[len(A):], B)
+
+	return result
+}
+
+// legacyDecrypt inverts legacyEncrypt.
+// Input validation is performed by the caller.
+func (f *FF1) legacyDecrypt(ciphertext []uint16, radix int) []uint16 {
+	n := len(ciphertext)
+
+	// Step 1: Split into left and right halves
+	u := n / 2
+	v := n - u
+
+	// Start with the final state from encryption
+	A := make([]uint16, u)
+	B := make([]uint16, v)
+	copy(A, ciphertext[:u])
+	copy(B, ciphertext[u:])
+
+	// Get properly sized key
+	aesKey := f.legacyAESKey()
+
+	// Number of rounds (same as encryption)
+	rounds := 10
+
+	// Decrypt by running rounds in reverse
+	for i := rounds - 1; i >= 0; i-- {
+		// Current sizes: A has size len(A), B has size len(B)
+		// F function: compute on A (which was B_i), output should have size len(B)
+		currentU := len(B) // Output size (size of B, which was A_{i+1})
+		currentV := len(A) // Input size (size of A, which was B_i)
+		C := f.legacyFeistelFunction(A, i, currentU, currentV, n, radix, aesKey)
+
+		// Ensure C has exactly len(B) elements
+		if len(C) != len(B) {
+			// Pad or truncate C to match B's length
+			newC := make([]uint16, len(B))
+			for j := 0; j < len(B); j++ {
+				if j < len(C) {
+					newC[j] = C[j]
+				} else {
+					newC[j] = 0
+				}
+			}
+			C = newC
+		}
+
+		// Recover A_i: A_i = (B_{i+1} - C + radix) mod radix
+		oldA := make([]uint16, len(B))
+		for j := 0; j < len(B); j++ {
+			cIdx := j % len(C)
+			cVal := uint32(C[cIdx])
+			val := uint32(B[j]) + uint32(radix) - cVal
+			oldA[j] = uint16(val % uint32(radix))
+		}
+
+		// Recover B_i: B_i = A_{i+1} = current A
+		oldB := make([]uint16, len(A))
+		copy(oldB, A)
+
+		// Update for next iteration: A = A_i, B = B_i
+		A = oldA
+		B = oldB
+	}
+
+	// After all rounds, we have A = A_0 (u elements), B = B_0 (v elements)
+	result := make([]uint16, n)
+	copy(result, A)
+	copy(result[len(A):], B)
+
+	return result
+}
+
+// legacyFeistelFunction implements the legacy round function.
+func (f *FF1) legacyFeistelFunction(B []uint16, roundNum, u, v, n, radix int, aesKey []byte) []uint16 {
+	m := len(B)
+	if m == 0 {
+		return make([]uint16, u)
+	}
+
+	// Step 6.i: Build Q array
+	Q := f.legacyQArray(roundNum, B, radix)
+
+	// Step 6.ii: Encrypt Q with AES to get R
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		// This should not happen with valid key sizes, but handle gracefully
+		return make([]uint16, u)
+	}
+
+	// Q must be padded to AES block size (16 bytes)
+	blockSize := aes.BlockSize
+	qLen := len(Q)
+	paddedLen := ((qLen + blockSize - 1) / blockSize) * blockSize
+	Q_padded := make([]byte, paddedLen)
+	copy(Q_padded, Q)
+
+	// Encrypt Q_padded with AES-ECB
+	R := make([]byte, paddedLen)
+	for i := 0; i < paddedLen; i += blockSize {
+		block.Encrypt(R[i:], Q_padded[i:])
+	}
+
+	// Step 6.iii: Extract S (first d bytes of R)
+	d := (u*bitLength(radix) + 7) / 8
+	if d < 1 {
+		d = 1
+	}
+	if d > len(R) {
+		d = len(R)
+	}
+	// For small outputs, use more bytes for better distribution
+	if d < 8 && len(R) >= 8 {
+		d = 8
+	}
+	S := R[:d]
+
+	// Step 6.iv: Convert S to integer y (big-endian)
+	y := new(big.Int).SetBytes(S)
+
+	// Step 6.v: m is the output length (u)
+	// Step 6.vi: Compute c = y mod (radix^m)
+	radixBig := big.NewInt(int64(radix))
+	radixPowM := new(big.Int).Exp(radixBig, big.NewInt(int64(u)), nil)
+	c := new(big.Int).Mod(y, radixPowM)
+
+	// Step 6.vii: Convert c to base-radix representation of length u
+	C := numradixDecode(c, radix, u)
+
+	return C
+}
+
+// legacyQArray constructs the legacy Q array for a specific round.
+func (f *FF1) legacyQArray(roundNum int, B []uint16, radix int) []byte {
+	// Q starts with 4 bytes of round number
+	Q := make([]byte, 0)
+	Q = append(Q, byte(roundNum))
+	Q = append(Q, byte(roundNum))
+	Q = append(Q, byte(roundNum))
+	Q = append(Q, byte(roundNum))
+
+	// Add tweak
+	Q = append(Q, f.tweak...)
+
+	// Add B array encoded using numradix
+	B_bytes := numradixToBytes(B, radix)
+	Q = append(Q, B_bytes...)
+
+	// Pad Q to AES block size (16 bytes) boundary
+	blockSize := aes.BlockSize
+	qLen := len(Q)
+	paddedLen := ((qLen + blockSize - 1) / blockSize) * blockSize
+	if paddedLen > qLen {
+		padding := make([]byte, paddedLen-qLen)
+		Q = append(Q, padding...)
+	}
+
+	return Q
+}
+
+// legacyAESKey returns the key properly sized (16, 24, or 32 bytes).
+// Legacy mode accepted any key of at least 16 bytes and truncated it to the
+// next smaller AES key size.
+func (f *FF1) legacyAESKey() []byte {
+	keyLen := len(f.key)
+
+	// AES supports 16, 24, or 32 byte keys
+	if keyLen == 16 || keyLen == 24 || keyLen == 32 {
+		return f.key
+	}
+
+	// If key is < 16 bytes, pad to 16
+	if keyLen < 16 {
+		padded := make([]byte, 16)
+		copy(padded, f.key)
+		return padded
+	}
+
+	// If key is between sizes, use the next smaller standard size
+	if keyLen < 24 {
+		return f.key[:16]
+	}
+	if keyLen < 32 {
+		return f.key[:24]
+	}
+
+	// If key is > 32 bytes, use first 32
+	return f.key[:32]
+}
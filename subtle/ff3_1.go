@@ -0,0 +1,263 @@
+package subtle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+)
+
+const (
+	// FF31TweakSize is the tweak length in bytes required by FF3-1 (56 bits).
+	FF31TweakSize = 7
+
+	// ff31Rounds is the number of Feistel rounds used by FF3-1.
+	ff31Rounds = 8
+
+	// ff31MinDomainSize is the smallest radix^minlen permitted by SP 800-38G Rev. 1.
+	ff31MinDomainSize = 1000000
+)
+
+// FF31 implements the NIST SP 800-38G Rev. 1 FF3-1 algorithm using raw keys.
+// Unlike FF1, FF3-1 requires a fixed 56-bit tweak and bounds the input length
+// by the radix so that each half fits in 96 bits.
+type FF31 struct {
+	tweak []byte
+	block cipher.Block
+}
+
+// NewFF31 creates a new FF3-1 instance with the given raw key and tweak.
+// The key must be 16, 24, or 32 bytes (AES-128, AES-192, or AES-256) and the
+// tweak must be exactly 7 bytes.
+func NewFF31(key, tweak []byte) (*FF31, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size: %d bytes (must be 16, 24, or 32)", len(key))
+	}
+	if len(tweak) != FF31TweakSize {
+		return nil, fmt.Errorf("invalid tweak size: %d bytes (FF3-1 requires %d)", len(tweak), FF31TweakSize)
+	}
+
+	// FF3-1 uses the block cipher keyed with the byte-reversed key, REVB(K)
+	block, err := aes.NewCipher(revb(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	return &FF31{
+		tweak: append([]byte(nil), tweak...),
+		block: block,
+	}, nil
+}
+
+// Encrypt performs FF3-1 format-preserving encryption on numeric data.
+// The radix is the number of characters (runes) in alphabet.
+//
+// Thread safety: This method is safe for concurrent use by multiple goroutines,
+// as it does not modify the FF31 instance state.
+func (f *FF31) Encrypt(plaintext []uint16, alphabet string) ([]uint16, error) {
+	radix := utf8.RuneCountInString(alphabet)
+	if err := validateFF31(plaintext, radix); err != nil {
+		return nil, err
+	}
+	return f.cipher(plaintext, radix, true), nil
+}
+
+// Decrypt performs FF3-1 format-preserving decryption on numeric data.
+// The radix is the number of characters (runes) in alphabet.
+//
+// Thread safety: This method is safe for concurrent use by multiple goroutines,
+// as it does not modify the FF31 instance state.
+func (f *FF31) Decrypt(ciphertext []uint16, alphabet string) ([]uint16, error) {
+	radix := utf8.RuneCountInString(alphabet)
+	if err := validateFF31(ciphertext, radix); err != nil {
+		return nil, err
+	}
+	return f.cipher(ciphertext, radix, false), nil
+}
+
+// EncryptWithTweak performs FF3-1 encryption as Encrypt does, but with tweak in
+// place of the tweak the instance was created with. The tweak must be 7 bytes.
+func (f *FF31) EncryptWithTweak(plaintext []uint16, alphabet string, tweak []byte) ([]uint16, error) {
+	g, err := f.WithTweak(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return g.Encrypt(plaintext, alphabet)
+}
+
+// DecryptWithTweak performs FF3-1 decryption as Decrypt does, but with tweak in
+// place of the tweak the instance was created with. The tweak must be 7 bytes.
+func (f *FF31) DecryptWithTweak(ciphertext []uint16, alphabet string, tweak []byte) ([]uint16, error) {
+	g, err := f.WithTweak(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return g.Decrypt(ciphertext, alphabet)
+}
+
+// Tweak returns the tweak the instance was created with.
+func (f *FF31) Tweak() []byte {
+	return f.tweak
+}
+
+// TweakSize returns FF31TweakSize.
+func (f *FF31) TweakSize() int {
+	return FF31TweakSize
+}
+
+// MinDomainSize returns the smallest radix^length accepted by Encrypt and Decrypt.
+func (f *FF31) MinDomainSize() int {
+	return ff31MinDomainSize
+}
+
+// WithTweak returns a copy of f that uses tweak, which must be 7 bytes. The
+// copy shares the block cipher, which is safe for concurrent use.
+func (f *FF31) WithTweak(tweak []byte) (*FF31, error) {
+	if len(tweak) != FF31TweakSize {
+		return nil, fmt.Errorf("invalid tweak size: %d bytes (FF3-1 requires %d)", len(tweak), FF31TweakSize)
+	}
+	g := *f
+	g.tweak = append([]byte(nil), tweak...)
+	return &g, nil
+}
+
+// validateFF31 checks the radix, length and domain rules of SP 800-38G Rev. 1:
+// radix in [2..2^16], radix^minlen >= 1,000,000, minlen >= 2 and
+// maxlen = 2 * floor(log_radix(2^96)).
+func validateFF31(X []uint16, radix int) error {
+	n := len(X)
+
+	if radix < 2 || radix > 1<<16 {
+		return fmt.Errorf("invalid radix: %d (must be between 2 and 65536)", radix)
+	}
+	if n < 2 {
+		return fmt.Errorf("input too short: %d characters (minimum 2)", n)
+	}
+
+	domainSize := new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(n)), nil)
+	if domainSize.Cmp(big.NewInt(ff31MinDomainSize)) < 0 {
+		return fmt.Errorf("domain size too small: radix=%d, length=%d, domain_size=%s (minimum %d required by FF3-1)", radix, n, domainSize.String(), ff31MinDomainSize)
+	}
+
+	if maxLen := ff31MaxLength(radix); n > maxLen {
+		return fmt.Errorf("input too long: %d characters (FF3-1 maximum for radix %d is %d)", n, radix, maxLen)
+	}
+
+	for i, x := range X {
+		if int(x) >= radix {
+			return fmt.Errorf("numeral %d at position %d is out of range for radix %d", x, i, radix)
+		}
+	}
+
+	return nil
+}
+
+// ff31MaxLength returns 2 * floor(log_radix(2^96)).
+func ff31MaxLength(radix int) int {
+	limit := new(big.Int).Lsh(big.NewInt(1), 96)
+	radixBig := big.NewInt(int64(radix))
+	pow := new(big.Int).Set(radixBig)
+	k := 0
+	for pow.Cmp(limit) <= 0 {
+		k++
+		pow.Mul(pow, radixBig)
+	}
+	return 2 * k
+}
+
+// cipher runs the FF3-1 Feistel network as specified in NIST SP 800-38G Rev. 1,
+// Algorithm 9 (encrypt) and Algorithm 10 (decrypt).
+func (f *FF31) cipher(X []uint16, radix int, encrypt bool) []uint16 {
+	n := len(X)
+
+	// Steps 1-2: u = ceil(n/2), v = n - u; A = X[1..u], B = X[u+1..n]
+	u := (n + 1) / 2
+	v := n - u
+	A := append([]uint16(nil), X[:u]...)
+	B := append([]uint16(nil), X[u:]...)
+
+	// Step 3: T_L = T[0..27] || 0^4, T_R = T[32..55] || T[28..31] || 0^4
+	var tl, tr [4]byte
+	copy(tl[:3], f.tweak[:3])
+	tl[3] = f.tweak[3] & 0xF0
+	copy(tr[:3], f.tweak[4:7])
+	tr[3] = f.tweak[3] << 4
+
+	radixBig := big.NewInt(int64(radix))
+	radixPowU := new(big.Int).Exp(radixBig, big.NewInt(int64(u)), nil)
+	radixPowV := new(big.Int).Exp(radixBig, big.NewInt(int64(v)), nil)
+
+	P := make([]byte, aes.BlockSize)
+	S := make([]byte, aes.BlockSize)
+	y := new(big.Int)
+	c := new(big.Int)
+
+	for r := 0; r < ff31Rounds; r++ {
+		i := r
+		if !encrypt {
+			i = ff31Rounds - 1 - r
+		}
+
+		// Step 4.i: m = u, W = T_R if i is even; m = v, W = T_L otherwise
+		m, W, radixPowM := u, tr, radixPowU
+		if i%2 == 1 {
+			m, W, radixPowM = v, tl, radixPowV
+		}
+
+		// Step 4.ii: P = W xor [i]^4 || [NUM_radix(REV(B))]^12
+		// (A takes the place of B when decrypting)
+		copy(P[:4], W[:])
+		P[3] ^= byte(i)
+		in := B
+		if !encrypt {
+			in = A
+		}
+		numradixEncode(rev(in), radix).FillBytes(P[4:])
+
+		// Step 4.iii: S = REVB(CIPH_REVB(K)(REVB(P)))
+		f.block.Encrypt(S, revb(P))
+		S = revb(S)
+
+		// Step 4.iv: y = NUM(S)
+		y.SetBytes(S)
+
+		if encrypt {
+			// Steps 4.v-4.viii: c = (NUM_radix(REV(A)) + y) mod radix^m;
+			// C = REV(STR^m_radix(c)); A = B; B = C
+			c.Add(numradixEncode(rev(A), radix), y)
+			c.Mod(c, radixPowM)
+			A, B = B, rev(numradixDecode(c, radix, m))
+		} else {
+			// Inverse round: c = (NUM_radix(REV(B)) - y) mod radix^m;
+			// C = REV(STR^m_radix(c)); B = A; A = C
+			c.Sub(numradixEncode(rev(B), radix), y)
+			c.Mod(c, radixPowM)
+			B, A = A, rev(numradixDecode(c, radix, m))
+		}
+	}
+
+	// Step 5: return A || B
+	result := make([]uint16, n)
+	copy(result, A)
+	copy(result[u:], B)
+	return result
+}
+
+// rev returns the numerals of X in reverse order.
+func rev(X []uint16) []uint16 {
+	out := make([]uint16, len(X))
+	for i, x := range X {
+		out[len(X)-1-i] = x
+	}
+	return out
+}
+
+// revb returns the bytes of X in reverse order.
+func revb(X []byte) []byte {
+	out := make([]byte, len(X))
+	for i, x := range X {
+		out[len(X)-1-i] = x
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package subtle
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// maxExpectedWalkSteps bounds the average number of cipher calls a range
+	// may need. Ranges far smaller than the cipher's minimum domain are rejected.
+	maxExpectedWalkSteps = 1000
+
+	// maxWalkSteps bounds the cipher calls of a single EncryptRange or
+	// DecryptRange call so that a pathological cycle cannot run unbounded.
+	maxWalkSteps = 1 << 20
+)
+
+// binaryAlphabet is the radix-2 alphabet ranges are encrypted over.
+const binaryAlphabet = "01"
+
+// EncryptRange encrypts x, an integer in [min, max] (both inclusive), to
+// another integer in [min, max] using c with cycle walking.
+//
+// x - min is written as the shortest bit string that holds max - min, widened
+// if needed to meet the minimum domain size of c, and encrypted over radix 2
+// until the result falls inside the range again. Because c is a permutation
+// the walk always ends, and the expected number of steps is the bit string
+// domain divided by the size of the range: less than two unless the range is
+// smaller than the cipher's minimum domain. Ranges that would need more than
+// 1000 steps on average are rejected.
+func EncryptRange(c Cipher, x, min, max *big.Int) (*big.Int, error) {
+	return cipherRange(c, x, min, max, true)
+}
+
+// DecryptRange inverts EncryptRange.
+func DecryptRange(c Cipher, y, min, max *big.Int) (*big.Int, error) {
+	return cipherRange(c, y, min, max, false)
+}
+
+// EncryptRange encrypts x in [min, max] to another integer in [min, max].
+// See the EncryptRange function.
+func (f *FF1) EncryptRange(x, min, max *big.Int) (*big.Int, error) {
+	if f.mode == ModeLegacy {
+		return nil, fmt.Errorf("EncryptRange is not available in %v mode", f.mode)
+	}
+	return EncryptRange(f, x, min, max)
+}
+
+// DecryptRange inverts EncryptRange.
+func (f *FF1) DecryptRange(y, min, max *big.Int) (*big.Int, error) {
+	if f.mode == ModeLegacy {
+		return nil, fmt.Errorf("DecryptRange is not available in %v mode", f.mode)
+	}
+	return DecryptRange(f, y, min, max)
+}
+
+// EncryptRange encrypts x in [min, max] to another integer in [min, max].
+// See the EncryptRange function.
+func (f *FF31) EncryptRange(x, min, max *big.Int) (*big.Int, error) {
+	return EncryptRange(f, x, min, max)
+}
+
+// DecryptRange inverts EncryptRange.
+func (f *FF31) DecryptRange(y, min, max *big.Int) (*big.Int, error) {
+	return DecryptRange(f, y, min, max)
+}
+
+// cipherRange implements EncryptRange and DecryptRange.
+func cipherRange(c Cipher, x, min, max *big.Int, encrypt bool) (*big.Int, error) {
+	if x == nil || min == nil || max == nil {
+		return nil, fmt.Errorf("value and range bounds cannot be nil")
+	}
+	if min.Cmp(max) > 0 {
+		return nil, fmt.Errorf("invalid range: min %s is greater than max %s", min.String(), max.String())
+	}
+	if x.Cmp(min) < 0 || x.Cmp(max) > 0 {
+		return nil, fmt.Errorf("value %s is outside the range [%s, %s]", x.String(), min.String(), max.String())
+	}
+
+	// Work in [0, size) where size = max - min + 1
+	last := new(big.Int).Sub(max, min)
+	size := new(big.Int).Add(last, big.NewInt(1))
+
+	// n bits hold every value up to last; widen to the cipher's minimum domain
+	n := last.BitLen()
+	if n < 2 {
+		n = 2
+	}
+	domain := new(big.Int).Lsh(big.NewInt(1), uint(n))
+	if bounded, ok := c.(interface{ MinDomainSize() int }); ok {
+		for domain.Cmp(big.NewInt(int64(bounded.MinDomainSize()))) < 0 {
+			n++
+			domain.Lsh(domain, 1)
+		}
+	}
+	if limit := new(big.Int).Mul(size, big.NewInt(maxExpectedWalkSteps)); domain.Cmp(limit) > 0 {
+		return nil, fmt.Errorf("range of %s values is too small: cycle walking over %d bits would take more than %d steps on average", size.String(), n, maxExpectedWalkSteps)
+	}
+
+	v := new(big.Int).Sub(x, min)
+	numerals := make([]uint16, n)
+	for i := range numerals {
+		numerals[i] = uint16(v.Bit(n - 1 - i))
+	}
+
+	for step := 0; step < maxWalkSteps; step++ {
+		var err error
+		if encrypt {
+			numerals, err = c.Encrypt(numerals, binaryAlphabet)
+		} else {
+			numerals, err = c.Decrypt(numerals, binaryAlphabet)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		v.SetInt64(0)
+		for _, b := range numerals {
+			v.Lsh(v, 1)
+			v.SetBit(v, 0, uint(b))
+		}
+		if v.Cmp(size) < 0 {
+			return v.Add(v, min), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cycle walking did not return to the range after %d steps", maxWalkSteps)
+}
@@ -0,0 +1,424 @@
+package fpe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatAlphabets are the alphabets that a Format template can name with {name}.
+var formatAlphabets = map[string]*Alphabet{
+	"numeric":            AlphabetNumeric,
+	"alphanumeric":       AlphabetAlphanumeric,
+	"upper":              AlphabetUppercase,
+	"lower":              AlphabetLowercase,
+	"upper_alphanumeric": AlphabetUppercaseAlphanumeric,
+	"lower_alphanumeric": AlphabetLowercaseAlphanumeric,
+	"hex":                AlphabetHex,
+	"hex_upper":          AlphabetHexUpper,
+	"base32_crockford":   AlphabetBase32Crockford,
+}
+
+// formatShortcuts are the single-character classes of a Format template.
+var formatShortcuts = map[rune]string{
+	'#': "numeric",
+	'9': "numeric",
+	'A': "upper",
+	'a': "lower",
+	'*': "alphanumeric",
+}
+
+// Format is a compiled format template that decides, position by position,
+// which characters of a value are literals and which are data, and which
+// alphabet each data character is drawn from.
+//
+// A template is a sequence of:
+//
+//	#, 9      a digit (the "numeric" alphabet)
+//	A         an uppercase ASCII letter ("upper")
+//	a         a lowercase ASCII letter ("lower")
+//	*         a digit or ASCII letter ("alphanumeric")
+//	{name}    one character of the named alphabet
+//	[...]     an optional group: present or absent as a whole
+//	\c        the literal character c
+//
+// Any other character is a literal and must appear as-is. Built-in alphabet
+// names are numeric, alphanumeric, upper, lower, upper_alphanumeric,
+// lower_alphanumeric, hex, hex_upper and base32_crockford; more can be added
+// with ParseFormatWithAlphabets. For example "###-##-####" is an SSN and
+// "ID-[{upper}]AAA-9999" is an "ID-" literal, an optional extra letter, three
+// letters and four digits.
+//
+// Only data characters are encrypted and each keeps its alphabet, so literal
+// prefixes such as country codes stay readable. A Format is immutable, safe for
+// concurrent use, and serializes to JSON so that services can share one definition.
+type Format struct {
+	template  string
+	alphabets map[string]*Alphabet
+	segments  []formatSegment
+}
+
+// formatSegment is a run of elements that is either required or optional as a whole.
+type formatSegment struct {
+	optional bool
+	elements []formatElement
+}
+
+// formatElement matches a single character: a literal, or one character of class.
+type formatElement struct {
+	literal rune
+	class   *Alphabet
+	name    string
+}
+
+// matches reports whether r satisfies the element.
+func (e formatElement) matches(r rune) bool {
+	if e.class != nil {
+		return e.class.Contains(r)
+	}
+	return r == e.literal
+}
+
+// describe returns what the element expects, for error messages.
+func (e formatElement) describe() string {
+	if e.class != nil {
+		return e.name + " character"
+	}
+	return strconv.QuoteRune(e.literal)
+}
+
+// FormatError reports that a value does not match a Format.
+type FormatError struct {
+	// Template is the template of the Format.
+	Template string
+	// Position is the index of the first character (rune) that could not be matched.
+	Position int
+	// Expected describes what the Format expected at Position.
+	Expected string
+	// Found is the character at Position, or "" if the value ended there.
+	Found string
+}
+
+// Error implements the error interface.
+func (e *FormatError) Error() string {
+	found := "end of input"
+	if e.Found != "" {
+		found = strconv.Quote(e.Found)
+	}
+	return fmt.Sprintf("value does not match format %q at position %d: expected %s, found %s", e.Template, e.Position, e.Expected, found)
+}
+
+// ParseFormat compiles a format template that uses only built-in alphabet names.
+func ParseFormat(template string) (*Format, error) {
+	return ParseFormatWithAlphabets(template, nil)
+}
+
+// ParseFormatWithAlphabets compiles a format template that may also refer to
+// the given alphabets by name. Custom names must not shadow built-in names.
+func ParseFormatWithAlphabets(template string, alphabets map[string]*Alphabet) (*Format, error) {
+	custom := make(map[string]*Alphabet, len(alphabets))
+	for name, alphabet := range alphabets {
+		if _, ok := formatAlphabets[name]; ok {
+			return nil, fmt.Errorf("alphabet name %q is reserved", name)
+		}
+		if name == "" || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("invalid alphabet name %q", name)
+		}
+		if alphabet == nil {
+			return nil, fmt.Errorf("alphabet %q cannot be nil", name)
+		}
+		custom[name] = alphabet
+	}
+
+	f := &Format{template: template, alphabets: custom}
+	lookup := func(name string) (*Alphabet, bool) {
+		if a, ok := custom[name]; ok {
+			return a, true
+		}
+		a, ok := formatAlphabets[name]
+		return a, ok
+	}
+
+	runes := []rune(template)
+	var current *formatSegment
+	inGroup := false
+	add := func(e formatElement) {
+		if current == nil {
+			f.segments = append(f.segments, formatSegment{})
+			current = &f.segments[len(f.segments)-1]
+		}
+		current.elements = append(current.elements, e)
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("invalid format %q: trailing escape character", template)
+			}
+			i++
+			add(formatElement{literal: runes[i]})
+		case r == '[':
+			if inGroup {
+				return nil, fmt.Errorf("invalid format %q: nested optional group at position %d", template, i)
+			}
+			inGroup = true
+			f.segments = append(f.segments, formatSegment{optional: true})
+			current = &f.segments[len(f.segments)-1]
+		case r == ']':
+			if !inGroup {
+				return nil, fmt.Errorf("invalid format %q: unmatched ']' at position %d", template, i)
+			}
+			if len(current.elements) == 0 {
+				return nil, fmt.Errorf("invalid format %q: empty optional group at position %d", template, i)
+			}
+			inGroup = false
+			current = nil
+		case r == '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("invalid format %q: unterminated alphabet name at position %d", template, i)
+			}
+			name := string(runes[i+1 : end])
+			alphabet, ok := lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("invalid format %q: unknown alphabet %q", template, name)
+			}
+			add(formatElement{class: alphabet, name: name})
+			i = end
+		default:
+			if name, ok := formatShortcuts[r]; ok {
+				add(formatElement{class: formatAlphabets[name], name: name})
+			} else {
+				add(formatElement{literal: r})
+			}
+		}
+	}
+
+	if inGroup {
+		return nil, fmt.Errorf("invalid format %q: unterminated optional group", template)
+	}
+	if len(f.segments) == 0 {
+		return nil, fmt.Errorf("format template cannot be empty")
+	}
+	return f, nil
+}
+
+// MustParseFormat is ParseFormat for templates known to be valid; it panics on error.
+func MustParseFormat(template string) *Format {
+	f, err := ParseFormat(template)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// String returns the template the Format was compiled from.
+func (f *Format) String() string {
+	return f.template
+}
+
+// Match reports whether s matches the Format. The returned error is a
+// *FormatError if it does not.
+func (f *Format) Match(s string) error {
+	if _, err := f.match([]rune(s)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// formatJSON is the serialized form of a Format.
+type formatJSON struct {
+	Template  string            `json:"template"`
+	Alphabets map[string]string `json:"alphabets,omitempty"`
+}
+
+// MarshalJSON encodes the Format as its template and any custom alphabets.
+func (f *Format) MarshalJSON() ([]byte, error) {
+	v := formatJSON{Template: f.template}
+	if len(f.alphabets) > 0 {
+		v.Alphabets = make(map[string]string, len(f.alphabets))
+		for name, alphabet := range f.alphabets {
+			v.Alphabets[name] = alphabet.String()
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a Format encoded by MarshalJSON and compiles it.
+func (f *Format) UnmarshalJSON(data []byte) error {
+	var v formatJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	// Build custom alphabets in name order so errors are deterministic
+	names := make([]string, 0, len(v.Alphabets))
+	for name := range v.Alphabets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var alphabets map[string]*Alphabet
+	for _, name := range names {
+		alphabet, err := NewAlphabet(v.Alphabets[name])
+		if err != nil {
+			return fmt.Errorf("alphabet %q: %w", name, err)
+		}
+		if alphabets == nil {
+			alphabets = make(map[string]*Alphabet, len(names))
+		}
+		alphabets[name] = alphabet
+	}
+
+	parsed, err := ParseFormatWithAlphabets(v.Template, alphabets)
+	if err != nil {
+		return err
+	}
+	*f = *parsed
+	return nil
+}
+
+// match finds the first way s fits the Format, trying each optional group
+// present before absent, and returns which optional segments are present.
+func (f *Format) match(s []rune) ([]bool, *FormatError) {
+	m := &formatMatcher{
+		format:  f,
+		input:   s,
+		present: make([]bool, len(f.segments)),
+		failed:  make(map[[2]int]bool),
+		failPos: -1,
+	}
+	if m.matchFrom(0, 0) {
+		return m.present, nil
+	}
+
+	err := &FormatError{Template: f.template, Position: m.failPos, Expected: m.expected}
+	if m.failPos < len(s) {
+		err.Found = string(s[m.failPos])
+	}
+	return nil, err
+}
+
+// layout returns one element per character of a value matched with present.
+func (f *Format) layout(present []bool) []formatElement {
+	var elements []formatElement
+	for i, seg := range f.segments {
+		if !seg.optional || present[i] {
+			elements = append(elements, seg.elements...)
+		}
+	}
+	return elements
+}
+
+// dataLayout matches s and returns the positions and alphabets of its data
+// characters for Tokenizer.layout.
+//
+// A result could match the format through different optional groups than s did
+// (e.g. "[#]##" read as three digits or as two), and its inverse would then use
+// the wrong layout. The returned check rejects such results so that the
+// Tokenizer cycle walks until the result is matched exactly like s.
+func (f *Format) dataLayout(s []rune) ([]int, []*Alphabet, func([]rune) bool, error) {
+	present, ferr := f.match(s)
+	if ferr != nil {
+		return nil, nil, nil, ferr
+	}
+
+	var positions []int
+	var classes []*Alphabet
+	for i, e := range f.layout(present) {
+		if e.class != nil {
+			positions = append(positions, i)
+			classes = append(classes, e.class)
+		}
+	}
+
+	check := func(out []rune) bool {
+		got, ferr := f.match(out)
+		return ferr == nil && equalLayout(got, present)
+	}
+	return positions, classes, check, nil
+}
+
+// equalLayout reports whether two matches chose the same optional groups.
+func equalLayout(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatMatcher is the backtracking state of Format.match.
+type formatMatcher struct {
+	format  *Format
+	input   []rune
+	present []bool
+
+	// failed memoizes (segment, position) pairs that cannot complete a match.
+	failed map[[2]int]bool
+
+	// failPos and expected record the furthest point any attempt reached.
+	failPos  int
+	expected string
+}
+
+// matchFrom reports whether segments[seg:] match input[pos:].
+func (m *formatMatcher) matchFrom(seg, pos int) bool {
+	if seg == len(m.format.segments) {
+		if pos == len(m.input) {
+			return true
+		}
+		m.fail(pos, "end of input")
+		return false
+	}
+	key := [2]int{seg, pos}
+	if m.failed[key] {
+		return false
+	}
+
+	s := m.format.segments[seg]
+	if end, ok := m.matchElements(s.elements, pos); ok {
+		m.present[seg] = true
+		if m.matchFrom(seg+1, end) {
+			return true
+		}
+	}
+	if s.optional {
+		m.present[seg] = false
+		if m.matchFrom(seg+1, pos) {
+			return true
+		}
+	}
+
+	m.failed[key] = true
+	return false
+}
+
+// matchElements matches elements against input starting at pos and returns the
+// position after them.
+func (m *formatMatcher) matchElements(elements []formatElement, pos int) (int, bool) {
+	for _, e := range elements {
+		if pos >= len(m.input) || !e.matches(m.input[pos]) {
+			m.fail(pos, e.describe())
+			return 0, false
+		}
+		pos++
+	}
+	return pos, true
+}
+
+// fail records a mismatch at pos if no attempt has got further.
+func (m *formatMatcher) fail(pos int, expected string) {
+	if pos > m.failPos {
+		m.failPos = pos
+		m.expected = expected
+	}
+}
@@ -0,0 +1,192 @@
+package fpe
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestParseFormatErrors verifies that malformed templates are rejected.
+func TestParseFormatErrors(t *testing.T) {
+	testCases := []string{
+		"",
+		"###\\",
+		"[##[#]]",
+		"##]",
+		"##[]",
+		"[###",
+		"{hex",
+		"{nosuchalphabet}##",
+	}
+
+	for _, template := range testCases {
+		if _, err := ParseFormat(template); err == nil {
+			t.Errorf("ParseFormat(%q) should have failed", template)
+		}
+	}
+
+	if _, err := ParseFormatWithAlphabets("{hex}", map[string]*Alphabet{"hex": AlphabetHex}); err == nil {
+		t.Error("Expected an error for a custom alphabet shadowing a built-in name")
+	}
+}
+
+// TestFormatMatch verifies matching, including optional groups, and the
+// structured error for values that do not match.
+func TestFormatMatch(t *testing.T) {
+	testCases := []struct {
+		template string
+		value    string
+		position int // -1 if the value matches
+	}{
+		{"###-##-####", "123-45-6789", -1},
+		{"###-##-####", "123456789", 3},
+		{"###-##-####", "123-45-678", 10},
+		{"###-##-####", "123-45-67890", 11},
+		{"AAA-9999", "ABC-1234", -1},
+		{"AAA-9999", "AbC-1234", 1},
+		{"ID-[{upper}]AAA-9999", "ID-ABC-1234", -1},
+		{"ID-[{upper}]AAA-9999", "ID-XABC-1234", -1},
+		{"ID-[{upper}]AAA-9999", "XD-ABC-1234", 0},
+		{"[+1 ]###-###-####", "+1 555-123-4567", -1},
+		{"[+1 ]###-###-####", "555-123-4567", -1},
+		{"{hex}{hex}:\\A", "9f:A", -1},
+	}
+
+	for _, tc := range testCases {
+		f, err := ParseFormat(tc.template)
+		if err != nil {
+			t.Fatalf("ParseFormat(%q) failed: %v", tc.template, err)
+		}
+
+		err = f.Match(tc.value)
+		if tc.position < 0 {
+			if err != nil {
+				t.Errorf("Match(%q, %q) failed: %v", tc.template, tc.value, err)
+			}
+			continue
+		}
+
+		var formatErr *FormatError
+		if !errors.As(err, &formatErr) {
+			t.Errorf("Match(%q, %q) = %v, want *FormatError", tc.template, tc.value, err)
+			continue
+		}
+		if formatErr.Position != tc.position {
+			t.Errorf("Match(%q, %q) failed at position %d, want %d (%v)", tc.template, tc.value, formatErr.Position, tc.position, err)
+		}
+	}
+}
+
+// TestTokenizerWithFormat verifies that literals are preserved, data keeps its
+// alphabet, tokens match the format and round-trip from the token alone.
+func TestTokenizerWithFormat(t *testing.T) {
+	testCases := []struct {
+		template string
+		values   []string
+	}{
+		{"###-##-####", []string{"123-45-6789", "000-00-0000"}},
+		{"AAA-9999", []string{"ABC-1234", "ZZZ-0000"}},
+		{"ID-[{upper}]AAA-9999", []string{"ID-ABC-1234", "ID-XABC-1234"}},
+		{"[+1 ]###-###-####", []string{"+1 555-123-4567", "555-123-4567"}},
+		{"[#]##[#]##", []string{"1234", "12345", "123456"}},
+		{"DE{hex_upper}{hex_upper}*****", []string{"DE9Fabc12"}},
+	}
+
+	for _, tc := range testCases {
+		f := MustParseFormat(tc.template)
+		tokenizer, err := NewFF1Tokenizer(testKey, []byte("tweak"), WithFormat(f))
+		if err != nil {
+			t.Fatalf("NewFF1Tokenizer failed: %v", err)
+		}
+
+		for _, plaintext := range tc.values {
+			tokenized, err := tokenizer.Tokenize(plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize(%q) with %q failed: %v", plaintext, tc.template, err)
+			}
+			if err := f.Match(tokenized); err != nil {
+				t.Errorf("Token %q does not match %q: %v", tokenized, tc.template, err)
+			}
+			if len(tokenized) != len(plaintext) {
+				t.Errorf("Length not preserved: %q -> %q", plaintext, tokenized)
+			}
+
+			detokenized, err := tokenizer.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize(%q) failed: %v", tokenized, err)
+			}
+			if detokenized != plaintext {
+				t.Errorf("Round-trip failed: %s -> %s -> %s", plaintext, tokenized, detokenized)
+			}
+		}
+	}
+
+	// Literal letters are not encrypted
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithFormat(MustParseFormat("ID-AAA-9999")))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	tokenized, err := tokenizer.Tokenize("ID-ABC-1234")
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if tokenized[:3] != "ID-" {
+		t.Errorf("Literal prefix was not preserved: %s", tokenized)
+	}
+
+	var formatErr *FormatError
+	if _, err := tokenizer.Tokenize("ID-ABC-12345"); !errors.As(err, &formatErr) {
+		t.Errorf("Expected a *FormatError for a mismatched value, got %v", err)
+	}
+}
+
+// TestFormatJSON verifies that a Format, including custom alphabets, survives
+// serialization and tokenizes identically afterwards.
+func TestFormatJSON(t *testing.T) {
+	vowels, err := NewAlphabet("AEIOU")
+	if err != nil {
+		t.Fatalf("NewAlphabet failed: %v", err)
+	}
+	f, err := ParseFormatWithAlphabets("{vowel}{vowel}-####", map[string]*Alphabet{"vowel": vowels})
+	if err != nil {
+		t.Fatalf("ParseFormatWithAlphabets failed: %v", err)
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded Format
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal of %s failed: %v", data, err)
+	}
+	if decoded.String() != f.String() {
+		t.Errorf("Template changed: %q -> %q", f.String(), decoded.String())
+	}
+
+	original, err := NewFF1Tokenizer(testKey, nil, WithFormat(f))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	restored, err := NewFF1Tokenizer(testKey, nil, WithFormat(&decoded))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	a, err := original.Tokenize("AE-1234")
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	b, err := restored.Tokenize("AE-1234")
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if a != b {
+		t.Errorf("Deserialized format tokenizes differently: %s vs %s", a, b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"template":"[##"}`), &decoded); err == nil {
+		t.Error("Expected Unmarshal to reject an invalid template")
+	}
+}
@@ -18,3 +18,72 @@ type FPE interface {
 	// This is the inverse of Tokenize.
 	Detokenize(tokenized string, originalPlaintext string) (string, error)
 }
+
+// TweakableFPEv1 is an FPE primitive that also takes the tweak per call, as
+// TweakableFPE does for FPEv2. Tokenize and Detokenize use the tweak the
+// primitive was created with. It is implemented by the primitives of
+// tinkfpe.New.
+type TweakableFPEv1 interface {
+	FPE
+
+	// TokenizeWithTweak is Tokenize using tweak. FF3-1 tweaks are exactly
+	// 7 bytes; FF1 tweaks are at most subtle.FF1MaxTweakSize bytes.
+	TokenizeWithTweak(plaintext string, tweak []byte) (string, error)
+
+	// DetokenizeWithTweak decrypts a value produced by TokenizeWithTweak with
+	// the same tweak. originalPlaintext behaves as in Detokenize.
+	DetokenizeWithTweak(tokenized string, tweak []byte, originalPlaintext string) (string, error)
+}
+
+// FPEv2 is the v2 primitive interface. Detokenize needs only the token: the
+// format is fixed when the primitive is created (see WithAlphabet), so
+// Detokenize is guaranteed to invert Tokenize.
+type FPEv2 interface {
+	// Tokenize encrypts plaintext using format-preserving encryption.
+	// This is deterministic: same input always produces same output.
+	Tokenize(plaintext string) (string, error)
+
+	// Detokenize decrypts a value produced by Tokenize.
+	Detokenize(tokenized string) (string, error)
+}
+
+// TweakableFPE is an FPEv2 primitive that also takes the tweak per call, so
+// that a single primitive serves every tenant or column instead of one
+// primitive per tweak. Tokenize and Detokenize use the tweak the primitive was
+// created with. It is implemented by Tokenizer and the v2 primitives of the
+// tinkfpe package.
+type TweakableFPE interface {
+	FPEv2
+
+	// TokenizeWithTweak is Tokenize using tweak. FF3-1 tweaks are exactly
+	// 7 bytes; FF1 tweaks are at most subtle.FF1MaxTweakSize bytes.
+	TokenizeWithTweak(plaintext string, tweak []byte) (string, error)
+
+	// DetokenizeWithTweak decrypts a value produced by TokenizeWithTweak with
+	// the same tweak.
+	DetokenizeWithTweak(tokenized string, tweak []byte) (string, error)
+}
+
+// BytesFPE encrypts opaque binary values, such as fixed-length IDs stored in
+// BYTEA columns, without encoding them as text first. Ciphertexts have the
+// same length as the input. It is implemented by FF1, FF31 and the primitives
+// of the tinkfpe package.
+type BytesFPE interface {
+	// EncryptBytes encrypts data over radix 256, one numeral per byte.
+	EncryptBytes(data []byte) ([]byte, error)
+
+	// DecryptBytes inverts EncryptBytes.
+	DecryptBytes(data []byte) ([]byte, error)
+
+	// EncryptBits encrypts the first bitLen bits of data over radix 2. data
+	// must be (bitLen+7)/8 bytes long with its unused trailing bits zero.
+	EncryptBits(data []byte, bitLen int) ([]byte, error)
+
+	// DecryptBits inverts EncryptBits.
+	DecryptBits(data []byte, bitLen int) ([]byte, error)
+}
+
+var (
+	_ BytesFPE = (*FF1)(nil)
+	_ BytesFPE = (*FF31)(nil)
+)
@@ -3,9 +3,9 @@
 package tinkfpe
 
 import (
+	"bytes"
 	"fmt"
 
-	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
 	"github.com/vdparikh/fpe"
 	"github.com/vdparikh/fpe/subtle"
@@ -13,6 +13,8 @@ import (
 
 // New creates a new FPE primitive from a Tink keyset handle.
 // This is the main entry point for users following Tink's pattern.
+// The algorithm (FF1 or FF3-1) is chosen by the type of the primary key;
+// FF3-1 keys require a 7-byte tweak.
 //
 // Example:
 //
@@ -25,80 +27,289 @@ import (
 //	    return err
 //	}
 //	tokenized, err := primitive.Tokenize("123-45-6789")
-func New(handle *keyset.Handle, tweak []byte) (fpe.FPE, error) {
+//
+// Options fix the format at construction (see fpe.WithAlphabet,
+// fpe.WithClassPreservation and fpe.WithFormat); the returned primitive then
+// ignores the originalPlaintext argument of Detokenize.
+//
+// The returned primitive implements fpe.TweakableFPEv1, so the tweak can also
+// be given per call; keys with a fixed tweak accept only their own.
+func New(handle *keyset.Handle, tweak []byte, opts ...fpe.Option) (fpe.FPE, error) {
+	key, err := primaryKey(handle)
+	if err != nil {
+		return nil, err
+	}
+	if key.hint != nil {
+		return nil, fmt.Errorf("keys with a key hint need NewV2 or NewRotating")
+	}
+	c, err := key.bind(tweak, subtle.ModeNIST)
+	if err != nil {
+		return nil, err
+	}
+	impl := &fpeImpl{cipher: c, key: key, mode: subtle.ModeNIST}
+	if opts = withKeyOptions(key.options, opts); len(opts) > 0 {
+		if impl.tokenizer, err = fpe.NewTokenizer(c, opts...); err != nil {
+			return nil, err
+		}
+	}
+	return impl, nil
+}
+
+// NewV2 creates a v2 FPE primitive from a Tink keyset handle. The format is
+// fixed by opts (fpe.AlphabetAlphanumeric if none is given), so Detokenize
+// needs only the token. If the primary key has a key hint, tokens carry it in
+// place of a spare character that every value must hold (see
+// KeyTemplateWithParams).
+//
+// The primitive also takes the tweak per call (see fpe.TweakableFPE), so one
+// primitive can serve every tenant or column; tweak is then the default used
+// by Tokenize and Detokenize and may be nil for FF1 keys. Keys with a fixed
+// tweak accept only their own.
+//
+// Example:
+//
+//	primitive, err := tinkfpe.NewV2(handle, []byte("tweak"), fpe.WithAlphabet(fpe.AlphabetNumeric))
+//	tokenized, err := primitive.Tokenize("123-45-6789")
+//	plaintext, err := primitive.Detokenize(tokenized)
+//
+//	// One primitive for every tenant
+//	tokenized, err = primitive.TokenizeWithTweak("123-45-6789", []byte(tenantID))
+func NewV2(handle *keyset.Handle, tweak []byte, opts ...fpe.Option) (fpe.TweakableFPE, error) {
+	key, err := primaryKey(handle)
+	if err != nil {
+		return nil, err
+	}
+	return key.newTokenizer(tweak, opts)
+}
+
+// NewLegacy creates an FPE primitive that uses the pre-NIST round function of
+// earlier releases (see subtle.ModeLegacy). It exists so that tokens issued by
+// those releases can still be detokenized; use New for new tokens.
+func NewLegacy(handle *keyset.Handle, tweak []byte) (fpe.FPE, error) {
+	key, err := primaryKey(handle)
+	if err != nil {
+		return nil, err
+	}
+	if len(key.options) > 0 || key.hint != nil {
+		return nil, fmt.Errorf("NewLegacy does not support keys whose parameters fix an alphabet, format or key hint")
+	}
+	c, err := key.bind(tweak, subtle.ModeLegacy)
+	if err != nil {
+		return nil, err
+	}
+	return &fpeImpl{cipher: c, key: key, mode: subtle.ModeLegacy}, nil
+}
+
+// NewBytes creates a primitive that encrypts opaque binary values, such as
+// 16-byte device IDs, to values of the same length (see fpe.BytesFPE). The
+// primitive returned by New implements fpe.BytesFPE as well.
+//
+// Example:
+//
+//	primitive, err := tinkfpe.NewBytes(handle, []byte("device-id"))
+//	token, err := primitive.EncryptBytes(deviceID)
+//	deviceID, err = primitive.DecryptBytes(token)
+func NewBytes(handle *keyset.Handle, tweak []byte) (fpe.BytesFPE, error) {
+	key, err := primaryKey(handle)
+	if err != nil {
+		return nil, err
+	}
+	if key.hint != nil {
+		return nil, fmt.Errorf("key hints are not available for binary values")
+	}
+	c, err := key.bind(tweak, subtle.ModeNIST)
+	if err != nil {
+		return nil, err
+	}
+	return &fpeImpl{cipher: c, key: key, mode: subtle.ModeNIST}, nil
+}
+
+// primaryKey returns the primitive of the primary key of handle. The key is
+// turned into a primitive by the KeyManager registered for its type, so the
+// handle may come from any source: a cleartext keyset, keyset.Read with a
+// master AEAD, or a KMS envelope key (see NewKMSEnvelopeKeyManager).
+func primaryKey(handle *keyset.Handle) (*keyPrimitive, error) {
 	if handle == nil {
 		return nil, fmt.Errorf("keyset handle cannot be nil")
 	}
 
-	// Extract the primary key from the keyset using Tink's Primitives API
 	primitives, err := handle.Primitives()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get primitives from handle: %w", err)
 	}
-
-	// Get the primary entry (which contains the key)
-	primary := primitives.Primary
-	if primary == nil {
+	if primitives.Primary == nil {
 		return nil, fmt.Errorf("no primary key found in keyset")
 	}
+	key, ok := primitives.Primary.Primitive.(*keyPrimitive)
+	if !ok {
+		return nil, fmt.Errorf("primary key is not an FPE key: got primitive %T", primitives.Primary.Primitive)
+	}
+	return key, nil
+}
 
-	// Extract key material using the key ID from the primary entry
-	keyID := primary.KeyID
-	if keyID == 0 {
-		return nil, fmt.Errorf("invalid key ID in primary entry")
-	}
-
-	// Extract the keyset using insecurecleartextkeyset (for unencrypted keysets)
-	// This works for keysets created with insecurecleartextkeyset
-	ks := insecurecleartextkeyset.KeysetMaterial(handle)
-
-	// Find the key with matching ID
-	var keyBytes []byte
-	for _, key := range ks.Key {
-		if key.KeyId == keyID {
-			keyData := key.KeyData
-			if keyData == nil {
-				continue
-			}
-
-			// Handle encrypted keys via KMS
-			// Note: For encrypted keys, the KMS URI is typically in the keyset key structure,
-			// not in KeyData. Full KMS support would require additional keyset parsing.
-			keyMaterialType := keyData.GetKeyMaterialType()
-			if keyMaterialType == 1 { // ENCRYPTED = 1
-				return nil, fmt.Errorf("encrypted keys via KMS are not yet fully supported - use symmetric keys")
-			}
-
-			// For symmetric keys, return the value directly
-			// SYMMETRIC = 2
-			if keyMaterialType == 2 {
-				keyBytes = keyData.Value
-				break
-			}
+// bind returns the cipher of k with the given tweak and FF1 mode. Keys with a
+// fixed tweak accept only an empty tweak or their own.
+func (k *keyPrimitive) bind(tweak []byte, mode subtle.Mode) (subtle.Cipher, error) {
+	if k.fixed {
+		if err := k.checkFixedTweak(tweak); err != nil {
+			return nil, err
 		}
+		tweak = k.tweak
 	}
 
-	if keyBytes == nil {
-		return nil, fmt.Errorf("key with ID %d not found or unsupported key type", keyID)
+	switch c := k.cipher.(type) {
+	case *subtle.FF1:
+		ff1, err := c.WithMode(mode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create FF1 instance: %w", err)
+		}
+		return ff1.WithTweak(tweak), nil
+	case *subtle.FF31:
+		if mode != subtle.ModeNIST {
+			return nil, fmt.Errorf("%v mode is only available for FF1 keys", mode)
+		}
+		ff31, err := c.WithTweak(tweak)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create FF3-1 instance: %w", err)
+		}
+		return ff31, nil
+	default:
+		return nil, fmt.Errorf("unsupported FPE cipher %T", k.cipher)
 	}
+}
 
-	// Create FF1 instance from subtle package with the extracted key
-	ff1, err := subtle.NewFF1(keyBytes, tweak)
+// checkFixedTweak checks that tweak is empty or the fixed tweak of k.
+func (k *keyPrimitive) checkFixedTweak(tweak []byte) error {
+	if len(tweak) > 0 && !bytes.Equal(tweak, k.tweak) {
+		return fmt.Errorf("key has a fixed tweak: pass an empty tweak or the key's own")
+	}
+	return nil
+}
+
+// newTokenizer creates the v2 primitive of k with the given tweak and options,
+// writing the key hint of k into its tokens.
+func (k *keyPrimitive) newTokenizer(tweak []byte, opts []fpe.Option) (fpe.TweakableFPE, error) {
+	c, err := k.bind(tweak, subtle.ModeNIST)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create FF1 instance: %w", err)
+		return nil, err
+	}
+	opts = withKeyOptions(k.options, opts)
+	if k.hint != nil {
+		opts = append(opts, fpe.WithMarker(int(k.hint.GetPosition()), k.hint.GetValue(), k.hint.GetFill()))
+	}
+	var tokenizer fpe.TweakableFPE
+	if tokenizer, err = fpe.NewTokenizer(c, opts...); err != nil {
+		return nil, err
+	}
+	if k.fixed {
+		tokenizer = &fixedTweakTokenizer{tokenizer: tokenizer, key: k}
+	}
+	return tokenizer, nil
+}
+
+// fixedTweakTokenizer is the v2 primitive of a key with a fixed tweak, whose
+// tokenizer is bound to that tweak. Per-call tweaks must be empty or the same.
+type fixedTweakTokenizer struct {
+	tokenizer fpe.TweakableFPE
+	key       *keyPrimitive
+}
+
+// Tokenize encrypts plaintext with the fixed tweak.
+func (f *fixedTweakTokenizer) Tokenize(plaintext string) (string, error) {
+	return f.tokenizer.Tokenize(plaintext)
+}
+
+// Detokenize decrypts tokenized with the fixed tweak.
+func (f *fixedTweakTokenizer) Detokenize(tokenized string) (string, error) {
+	return f.tokenizer.Detokenize(tokenized)
+}
+
+// TokenizeWithTweak checks tweak against the fixed tweak and calls Tokenize.
+func (f *fixedTweakTokenizer) TokenizeWithTweak(plaintext string, tweak []byte) (string, error) {
+	if err := f.key.checkFixedTweak(tweak); err != nil {
+		return "", err
+	}
+	return f.tokenizer.Tokenize(plaintext)
+}
+
+// DetokenizeWithTweak checks tweak against the fixed tweak and calls Detokenize.
+func (f *fixedTweakTokenizer) DetokenizeWithTweak(tokenized string, tweak []byte) (string, error) {
+	if err := f.key.checkFixedTweak(tweak); err != nil {
+		return "", err
 	}
+	return f.tokenizer.Detokenize(tokenized)
+}
 
-	// Wrap in FPE interface
-	return &fpeImpl{ff1: ff1}, nil
+// withKeyOptions returns the options fixed by a key followed by opts.
+func withKeyOptions(keyOptions, opts []fpe.Option) []fpe.Option {
+	return append(append([]fpe.Option(nil), keyOptions...), opts...)
 }
 
-// fpeImpl implements the fpe.FPE interface using the subtle.FF1 implementation.
+// fpeImpl implements the fpe.FPE interface using a subtle.Cipher (FF1 or FF3-1).
+// When created with options, tokenizer fixes the format. Per-call tweaks bind
+// key to the tweak in mode.
 type fpeImpl struct {
-	ff1 *subtle.FF1
+	cipher    subtle.Cipher
+	tokenizer *fpe.Tokenizer
+	key       *keyPrimitive
+	mode      subtle.Mode
 }
 
 // Tokenize encrypts plaintext using format-preserving encryption.
 func (f *fpeImpl) Tokenize(plaintext string) (string, error) {
+	if f.tokenizer != nil {
+		return f.tokenizer.Tokenize(plaintext)
+	}
+	return tokenize(f.cipher, plaintext)
+}
+
+// Detokenize decrypts tokenized value using format-preserving encryption.
+func (f *fpeImpl) Detokenize(tokenized string, originalPlaintext string) (string, error) {
+	if f.tokenizer != nil {
+		return f.tokenizer.Detokenize(tokenized)
+	}
+	return detokenize(f.cipher, tokenized, originalPlaintext)
+}
+
+// TokenizeWithTweak is Tokenize using tweak (see fpe.TweakableFPEv1).
+func (f *fpeImpl) TokenizeWithTweak(plaintext string, tweak []byte) (string, error) {
+	if f.tokenizer != nil {
+		if !f.key.fixed {
+			return f.tokenizer.TokenizeWithTweak(plaintext, tweak)
+		}
+		if err := f.key.checkFixedTweak(tweak); err != nil {
+			return "", fmt.Errorf("failed to tokenize: %w", err)
+		}
+		return f.tokenizer.Tokenize(plaintext)
+	}
+	c, err := f.key.bind(tweak, f.mode)
+	if err != nil {
+		return "", fmt.Errorf("failed to tokenize: %w", err)
+	}
+	return tokenize(c, plaintext)
+}
+
+// DetokenizeWithTweak decrypts a value produced by TokenizeWithTweak with the
+// same tweak. originalPlaintext behaves as in Detokenize.
+func (f *fpeImpl) DetokenizeWithTweak(tokenized string, tweak []byte, originalPlaintext string) (string, error) {
+	if f.tokenizer != nil {
+		if !f.key.fixed {
+			return f.tokenizer.DetokenizeWithTweak(tokenized, tweak)
+		}
+		if err := f.key.checkFixedTweak(tweak); err != nil {
+			return "", fmt.Errorf("failed to detokenize: %w", err)
+		}
+		return f.tokenizer.Detokenize(tokenized)
+	}
+	c, err := f.key.bind(tweak, f.mode)
+	if err != nil {
+		return "", fmt.Errorf("failed to detokenize: %w", err)
+	}
+	return detokenize(c, tokenized, originalPlaintext)
+}
+
+// tokenize encrypts plaintext with c, detecting the alphabet from plaintext.
+func tokenize(c subtle.Cipher, plaintext string) (string, error) {
 	// Use the format handling from the parent package
 	formatMask, dataChars := fpe.SeparateFormatAndData(plaintext)
 	alphabet := fpe.DetermineAlphabet(dataChars)
@@ -108,7 +319,7 @@ func (f *fpeImpl) Tokenize(plaintext string) (string, error) {
 
 	// Convert to numeric and encrypt
 	dataNumeric := fpe.StringToNumeric(dataChars, alphabet)
-	tokenizedNumeric, err := f.ff1.Encrypt(dataNumeric, alphabet)
+	tokenizedNumeric, err := c.Encrypt(dataNumeric, alphabet)
 	if err != nil {
 		return "", fmt.Errorf("failed to tokenize: %w", err)
 	}
@@ -120,8 +331,9 @@ func (f *fpeImpl) Tokenize(plaintext string) (string, error) {
 	return tokenized, nil
 }
 
-// Detokenize decrypts tokenized value using format-preserving encryption.
-func (f *fpeImpl) Detokenize(tokenized string, originalPlaintext string) (string, error) {
+// detokenize decrypts tokenized with c, detecting the alphabet from
+// originalPlaintext if given and from tokenized otherwise.
+func detokenize(c subtle.Cipher, tokenized string, originalPlaintext string) (string, error) {
 	formatMask, dataChars := fpe.SeparateFormatAndData(tokenized)
 
 	// Determine alphabet (prefer from original plaintext if provided)
@@ -138,7 +350,7 @@ func (f *fpeImpl) Detokenize(tokenized string, originalPlaintext string) (string
 
 	// Convert to numeric and decrypt
 	tokenizedNumeric := fpe.StringToNumeric(dataChars, alphabet)
-	plaintextNumeric, err := f.ff1.Decrypt(tokenizedNumeric, alphabet)
+	plaintextNumeric, err := c.Decrypt(tokenizedNumeric, alphabet)
 	if err != nil {
 		return "", fmt.Errorf("failed to detokenize: %w", err)
 	}
@@ -150,5 +362,56 @@ func (f *fpeImpl) Detokenize(tokenized string, originalPlaintext string) (string
 	return plaintext, nil
 }
 
-// Verify that fpeImpl implements fpe.FPE
-var _ fpe.FPE = (*fpeImpl)(nil)
+// EncryptBytes encrypts data to a byte string of the same length over radix 256.
+func (f *fpeImpl) EncryptBytes(data []byte) ([]byte, error) {
+	c, err := f.bytesCipher()
+	if err != nil {
+		return nil, err
+	}
+	return c.EncryptBytes(data)
+}
+
+// DecryptBytes inverts EncryptBytes.
+func (f *fpeImpl) DecryptBytes(data []byte) ([]byte, error) {
+	c, err := f.bytesCipher()
+	if err != nil {
+		return nil, err
+	}
+	return c.DecryptBytes(data)
+}
+
+// EncryptBits encrypts the first bitLen bits of data over radix 2.
+func (f *fpeImpl) EncryptBits(data []byte, bitLen int) ([]byte, error) {
+	c, err := f.bytesCipher()
+	if err != nil {
+		return nil, err
+	}
+	return c.EncryptBits(data, bitLen)
+}
+
+// DecryptBits inverts EncryptBits.
+func (f *fpeImpl) DecryptBits(data []byte, bitLen int) ([]byte, error) {
+	c, err := f.bytesCipher()
+	if err != nil {
+		return nil, err
+	}
+	return c.DecryptBits(data, bitLen)
+}
+
+// bytesCipher returns the cipher as an fpe.BytesFPE. Both subtle.FF1 and
+// subtle.FF31 qualify; subtle.FF1 rejects the calls in legacy mode.
+func (f *fpeImpl) bytesCipher() (fpe.BytesFPE, error) {
+	c, ok := f.cipher.(fpe.BytesFPE)
+	if !ok {
+		return nil, fmt.Errorf("cipher %T does not support byte encryption", f.cipher)
+	}
+	return c, nil
+}
+
+// Verify that fpeImpl implements fpe.TweakableFPEv1 and fpe.BytesFPE, and
+// fixedTweakTokenizer implements fpe.TweakableFPE
+var (
+	_ fpe.TweakableFPEv1 = (*fpeImpl)(nil)
+	_ fpe.BytesFPE       = (*fpeImpl)(nil)
+	_ fpe.TweakableFPE   = (*fixedTweakTokenizer)(nil)
+)
@@ -0,0 +1,587 @@
+package tinkfpe
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/google/tink/go/insecurecleartextkeyset"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/vdparikh/fpe"
+	"github.com/vdparikh/fpe/proto/fpe_go_proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestNewLegacyCompatibility verifies that NewLegacy reproduces tokens issued by
+// releases that predate NIST SP 800-38G conformance, and that New does not.
+func TestNewLegacyCompatibility(t *testing.T) {
+	_, err := getOrRegisterKeyManager()
+	if err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		key        string
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{"AES128", "2B7E151628AED2A6ABF7158809CF4F3C", "", "0123456789", "3047523683"},
+		{"AES192", "2B7E151628AED2A6ABF7158809CF4F3C2B7E151628AED2A6", "", "0123456789", "5336569521"},
+		{"AES256", "2B7E151628AED2A6ABF7158809CF4F3C2B7E151628AED2A6ABF7158809CF4F3C", "", "0123456789", "2449381452"},
+		{"AES128_Tweak", "2B7E151628AED2A6ABF7158809CF4F3C", "D8E7920AFA387A9E", "0123456789", "0006787907"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, _ := hex.DecodeString(tc.key)
+			tweak, _ := hex.DecodeString(tc.tweak)
+
+			handle, err := createKeysetHandleFromKey(key)
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+
+			legacy, err := NewLegacy(handle, tweak)
+			if err != nil {
+				t.Fatalf("NewLegacy() failed: %v", err)
+			}
+
+			tokenized, err := legacy.Tokenize(tc.plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if tokenized != tc.ciphertext {
+				t.Errorf("Legacy token mismatch: expected %s, got %s", tc.ciphertext, tokenized)
+			}
+
+			detokenized, err := legacy.Detokenize(tc.ciphertext, tc.plaintext)
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != tc.plaintext {
+				t.Errorf("Legacy round-trip failed: expected %s, got %s", tc.plaintext, detokenized)
+			}
+
+			primitive, err := New(handle, tweak)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			nistToken, err := primitive.Tokenize(tc.plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if nistToken == tokenized {
+				t.Errorf("NIST and legacy modes produced the same token %s", nistToken)
+			}
+		})
+	}
+}
+
+// TestNewSelectsAlgorithmFromKeyType verifies that New builds an FF3-1 primitive
+// for FF3-1 keysets and enforces the FF3-1 tweak length.
+func TestNewSelectsAlgorithmFromKeyType(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	if _, err := getOrRegisterFF31KeyManager(); err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+
+	handle, err := keyset.NewHandle(FF31KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create FF3-1 keyset handle: %v", err)
+	}
+
+	tweak := []byte("tenant1") // 7 bytes
+	primitive, err := New(handle, tweak)
+	if err != nil {
+		t.Fatalf("New() failed for FF3-1 keyset: %v", err)
+	}
+
+	plaintext := "4532-1234-5678-9010"
+	tokenized, err := primitive.Tokenize(plaintext)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	detokenized, err := primitive.Detokenize(tokenized, plaintext)
+	if err != nil {
+		t.Fatalf("Detokenize failed: %v", err)
+	}
+	if detokenized != plaintext {
+		t.Errorf("Round-trip failed: expected %s, got %s", plaintext, detokenized)
+	}
+
+	// The same key material under the FF1 key type must produce a different token
+	ks := insecurecleartextkeyset.KeysetMaterial(handle)
+	ff31Key := new(fpe_go_proto.FpeFf31Key)
+	if err := proto.Unmarshal(ks.Key[0].KeyData.Value, ff31Key); err != nil {
+		t.Fatalf("Failed to parse FF3-1 key: %v", err)
+	}
+	ff1Handle, err := createKeysetHandleFromKey(ff31Key.KeyValue)
+	if err != nil {
+		t.Fatalf("Failed to create FF1 keyset handle: %v", err)
+	}
+	ff1Primitive, err := New(ff1Handle, tweak)
+	if err != nil {
+		t.Fatalf("New() failed for FF1 keyset: %v", err)
+	}
+	ff1Token, err := ff1Primitive.Tokenize(plaintext)
+	if err != nil {
+		t.Fatalf("FF1 Tokenize failed: %v", err)
+	}
+	if ff1Token == tokenized {
+		t.Errorf("FF1 and FF3-1 produced the same token %s", tokenized)
+	}
+
+	if _, err := New(handle, []byte("tenant-1234|customer.ssn")); err == nil {
+		t.Error("Expected New() to reject a tweak that is not 7 bytes for FF3-1 keys")
+	}
+	if _, err := NewLegacy(handle, tweak); err == nil {
+		t.Error("Expected NewLegacy() to reject FF3-1 keys")
+	}
+}
+
+// TestNewV2DetokenizeWithoutPlaintext verifies that the v2 primitive inverts
+// Tokenize from the token alone, including values whose token would be
+// classified differently by alphabet detection.
+func TestNewV2DetokenizeWithoutPlaintext(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	handle, err := keyset.NewHandle(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		opts       []fpe.Option
+		plaintexts []string
+	}{
+		{
+			name:       "DefaultAlphanumeric",
+			plaintexts: []string{"AB12cd", "ABC123XYZ", "123-45-6789", "user@domain.com", "a1b2c3"},
+		},
+		{
+			name:       "Numeric",
+			opts:       []fpe.Option{fpe.WithAlphabet(fpe.AlphabetNumeric)},
+			plaintexts: []string{"123-45-6789", "4532-1234-5678-9010", "000"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			primitive, err := NewV2(handle, []byte("test-tweak"), tc.opts...)
+			if err != nil {
+				t.Fatalf("NewV2() failed: %v", err)
+			}
+
+			for _, plaintext := range tc.plaintexts {
+				tokenized, err := primitive.Tokenize(plaintext)
+				if err != nil {
+					t.Fatalf("Tokenize(%q) failed: %v", plaintext, err)
+				}
+				detokenized, err := primitive.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%q) failed: %v", tokenized, err)
+				}
+				if detokenized != plaintext {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", plaintext, tokenized, detokenized)
+				}
+			}
+		})
+	}
+
+	// Exhaustively cover short mixed values: with alphabet detection some of
+	// these tokenize to all digits and cannot be detokenized without the plaintext
+	primitive, err := NewV2(handle, []byte("test-tweak"))
+	if err != nil {
+		t.Fatalf("NewV2() failed: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		plaintext := fmt.Sprintf("A%03d", i)
+		tokenized, err := primitive.Tokenize(plaintext)
+		if err != nil {
+			t.Fatalf("Tokenize(%q) failed: %v", plaintext, err)
+		}
+		detokenized, err := primitive.Detokenize(tokenized)
+		if err != nil {
+			t.Fatalf("Detokenize(%q) failed: %v", tokenized, err)
+		}
+		if detokenized != plaintext {
+			t.Fatalf("Round-trip failed: %s -> %s -> %s", plaintext, tokenized, detokenized)
+		}
+	}
+}
+
+// TestNewWithAlphabetIgnoresOriginalPlaintext verifies that a v1 primitive with a
+// fixed alphabet detokenizes correctly without the original plaintext.
+func TestNewWithAlphabetIgnoresOriginalPlaintext(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	handle, err := keyset.NewHandle(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+
+	primitive, err := New(handle, []byte("test-tweak"), fpe.WithAlphabet(fpe.AlphabetAlphanumeric))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	plaintext := "AB12cd"
+	tokenized, err := primitive.Tokenize(plaintext)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	detokenized, err := primitive.Detokenize(tokenized, "")
+	if err != nil {
+		t.Fatalf("Detokenize failed: %v", err)
+	}
+	if detokenized != plaintext {
+		t.Errorf("Round-trip failed: expected %s, got %s", plaintext, detokenized)
+	}
+
+	if _, err := New(handle, nil, fpe.WithAlphabet(nil)); err == nil {
+		t.Error("Expected New() to reject a nil alphabet")
+	}
+}
+
+// TestNewWithClassPreservation verifies that both primitive flavours keep the
+// class of every position for FF1 and FF3-1 keys.
+func TestNewWithClassPreservation(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	if _, err := getOrRegisterFF31KeyManager(); err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		template *tink_go_proto.KeyTemplate
+		tweak    []byte
+	}{
+		{"FF1", KeyTemplate(), []byte("test-tweak")},
+		{"FF3-1", FF31KeyTemplate(), []byte("tweak-7")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handle, err := keyset.NewHandle(tc.template)
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+
+			v1, err := New(handle, tc.tweak, fpe.WithClassPreservation())
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			v2, err := NewV2(handle, tc.tweak, fpe.WithClassPreservation())
+			if err != nil {
+				t.Fatalf("NewV2() failed: %v", err)
+			}
+
+			plaintext := "AB12cd-3456"
+			tokenized, err := v2.Tokenize(plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			for i, r := range tokenized {
+				if classOf(r) != classOf(rune(plaintext[i])) {
+					t.Errorf("Class changed at %d: %s -> %s", i, plaintext, tokenized)
+				}
+			}
+
+			v1Tokenized, err := v1.Tokenize(plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if v1Tokenized != tokenized {
+				t.Errorf("New and NewV2 disagree: %s vs %s", v1Tokenized, tokenized)
+			}
+
+			detokenized, err := v1.Detokenize(tokenized, "")
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != plaintext {
+				t.Errorf("Round-trip failed: expected %s, got %s", plaintext, detokenized)
+			}
+		})
+	}
+}
+
+// TestNewBytes verifies that binary values round-trip with the same length
+// through NewBytes and the primitive returned by New, and that legacy
+// primitives reject byte encryption.
+func TestNewBytes(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	if _, err := getOrRegisterFF31KeyManager(); err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		template *tink_go_proto.KeyTemplate
+		tweak    []byte
+	}{
+		{"FF1", KeyTemplate(), []byte("device-id")},
+		{"FF3-1", FF31KeyTemplate(), []byte("tweak-7")},
+	}
+
+	deviceID := []byte{0x3f, 0x2a, 0x91, 0x00, 0x7c, 0xee, 0x41, 0x05, 0x9b, 0x10, 0x22, 0xd4, 0x68, 0x0f, 0xaa, 0x01}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handle, err := keyset.NewHandle(tc.template)
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+			primitive, err := NewBytes(handle, tc.tweak)
+			if err != nil {
+				t.Fatalf("NewBytes() failed: %v", err)
+			}
+
+			token, err := primitive.EncryptBytes(deviceID)
+			if err != nil {
+				t.Fatalf("EncryptBytes failed: %v", err)
+			}
+			if len(token) != len(deviceID) {
+				t.Errorf("EncryptBytes returned %d bytes, expected %d", len(token), len(deviceID))
+			}
+			back, err := primitive.DecryptBytes(token)
+			if err != nil {
+				t.Fatalf("DecryptBytes failed: %v", err)
+			}
+			if !bytes.Equal(back, deviceID) {
+				t.Errorf("Round-trip failed: %x -> %x -> %x", deviceID, token, back)
+			}
+
+			bitToken, err := primitive.EncryptBits([]byte{0x12, 0x34, 0x50}, 20)
+			if err != nil {
+				t.Fatalf("EncryptBits failed: %v", err)
+			}
+			bitBack, err := primitive.DecryptBits(bitToken, 20)
+			if err != nil {
+				t.Fatalf("DecryptBits failed: %v", err)
+			}
+			if !bytes.Equal(bitBack, []byte{0x12, 0x34, 0x50}) {
+				t.Errorf("Bit round-trip failed: got %x", bitBack)
+			}
+
+			v1, err := New(handle, tc.tweak)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			v1Bytes, ok := v1.(fpe.BytesFPE)
+			if !ok {
+				t.Fatalf("New() primitive %T does not implement fpe.BytesFPE", v1)
+			}
+			v1Token, err := v1Bytes.EncryptBytes(deviceID)
+			if err != nil {
+				t.Fatalf("EncryptBytes failed: %v", err)
+			}
+			if !bytes.Equal(v1Token, token) {
+				t.Errorf("New and NewBytes disagree: %x vs %x", v1Token, token)
+			}
+		})
+	}
+
+	handle, err := keyset.NewHandle(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+	legacy, err := NewLegacy(handle, nil)
+	if err != nil {
+		t.Fatalf("NewLegacy() failed: %v", err)
+	}
+	if _, err := legacy.(fpe.BytesFPE).EncryptBytes(deviceID); err == nil {
+		t.Error("Expected byte encryption to be unavailable in legacy mode")
+	}
+}
+
+// classOf returns 'd', 'u', 'l' or the rune itself for format characters.
+func classOf(r rune) rune {
+	switch {
+	case r >= '0' && r <= '9':
+		return 'd'
+	case r >= 'A' && r <= 'Z':
+		return 'u'
+	case r >= 'a' && r <= 'z':
+		return 'l'
+	default:
+		return r
+	}
+}
+
+// TestNewV2TokenizeWithTweak verifies that one v2 primitive serves many
+// tweaks, and that keys with a fixed tweak reject any other.
+func TestNewV2TokenizeWithTweak(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	handle, err := keyset.NewHandle(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+
+	shared, err := NewV2(handle, nil, fpe.WithSSN())
+	if err != nil {
+		t.Fatalf("NewV2 failed: %v", err)
+	}
+	ssn := "123-45-6789"
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		dedicated, err := NewV2(handle, []byte(tenant), fpe.WithSSN())
+		if err != nil {
+			t.Fatalf("NewV2 failed: %v", err)
+		}
+		want, err := dedicated.Tokenize(ssn)
+		if err != nil {
+			t.Fatalf("Tokenize failed: %v", err)
+		}
+		tokenized, err := shared.TokenizeWithTweak(ssn, []byte(tenant))
+		if err != nil || tokenized != want {
+			t.Errorf("TokenizeWithTweak(%s) = %s (%v), expected %s", tenant, tokenized, err, want)
+		}
+		if detokenized, err := shared.DetokenizeWithTweak(tokenized, []byte(tenant)); err != nil || detokenized != ssn {
+			t.Errorf("DetokenizeWithTweak(%s) = %s (%v), expected %s", tenant, detokenized, err, ssn)
+		}
+	}
+
+	template, err := KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+		Alphabet:    "0123456789",
+		TweakPolicy: fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED,
+		Tweak:       []byte("account"),
+		KeyHint:     &fpe_go_proto.FpeKeyHint{Value: "7", Fill: "0"},
+	})
+	if err != nil {
+		t.Fatalf("KeyTemplateWithParams failed: %v", err)
+	}
+	if handle, err = keyset.NewHandle(template); err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+	fixed, err := NewV2(handle, nil)
+	if err != nil {
+		t.Fatalf("NewV2 failed: %v", err)
+	}
+	want, err := fixed.Tokenize("0234567890")
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if tokenized, err := fixed.TokenizeWithTweak("0234567890", []byte("account")); err != nil || tokenized != want {
+		t.Errorf("TokenizeWithTweak with the fixed tweak = %s (%v), expected %s", tokenized, err, want)
+	}
+	if _, err := fixed.TokenizeWithTweak("0234567890", []byte("other")); err == nil {
+		t.Error("Expected TokenizeWithTweak to reject a tweak other than the key's fixed tweak")
+	}
+	if _, err := fixed.DetokenizeWithTweak(want, []byte("other")); err == nil {
+		t.Error("Expected DetokenizeWithTweak to reject a tweak other than the key's fixed tweak")
+	}
+}
+
+// TestNewTokenizeWithTweak verifies that the primitive of New takes the tweak
+// per call and gives the tokens of a primitive created with that tweak.
+func TestNewTokenizeWithTweak(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	if _, err := getOrRegisterFF31KeyManager(); err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		template *tink_go_proto.KeyTemplate
+		tenants  []string
+		opts     []fpe.Option
+	}{
+		{"FF1", KeyTemplate(), []string{"tenant-a", "tenant-b"}, nil},
+		{"FF1Alphabet", KeyTemplate(), []string{"tenant-a", "tenant-b"}, []fpe.Option{fpe.WithAlphabet(fpe.AlphabetNumeric)}},
+		{"FF3-1", FF31KeyTemplate(), []string{"tenantA", "tenantB"}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handle, err := keyset.NewHandle(tc.template)
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+			primitive, err := New(handle, []byte(tc.tenants[0]), tc.opts...)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			shared, ok := primitive.(fpe.TweakableFPEv1)
+			if !ok {
+				t.Fatalf("Expected New to return an fpe.TweakableFPEv1, got %T", primitive)
+			}
+
+			ssn := "123-45-6789"
+			for _, tenant := range tc.tenants {
+				dedicated, err := New(handle, []byte(tenant), tc.opts...)
+				if err != nil {
+					t.Fatalf("New failed: %v", err)
+				}
+				want, err := dedicated.Tokenize(ssn)
+				if err != nil {
+					t.Fatalf("Tokenize failed: %v", err)
+				}
+				tokenized, err := shared.TokenizeWithTweak(ssn, []byte(tenant))
+				if err != nil || tokenized != want {
+					t.Errorf("TokenizeWithTweak(%s) = %s (%v), expected %s", tenant, tokenized, err, want)
+				}
+				if detokenized, err := shared.DetokenizeWithTweak(tokenized, []byte(tenant), ssn); err != nil || detokenized != ssn {
+					t.Errorf("DetokenizeWithTweak(%s) = %s (%v), expected %s", tenant, detokenized, err, ssn)
+				}
+			}
+		})
+	}
+
+	handle, err := keyset.NewHandle(FF31KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+	primitive, err := New(handle, []byte("tweak77"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := primitive.(fpe.TweakableFPEv1).TokenizeWithTweak("123-45-6789", []byte("tweak")); err == nil {
+		t.Error("Expected TokenizeWithTweak to reject an FF3-1 tweak that is not 7 bytes")
+	}
+
+	template, err := KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+		Alphabet:    "0123456789",
+		TweakPolicy: fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED,
+		Tweak:       []byte("account"),
+	})
+	if err != nil {
+		t.Fatalf("KeyTemplateWithParams failed: %v", err)
+	}
+	if handle, err = keyset.NewHandle(template); err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+	if primitive, err = New(handle, nil); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	fixed := primitive.(fpe.TweakableFPEv1)
+	want, err := fixed.Tokenize("1234567890")
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if tokenized, err := fixed.TokenizeWithTweak("1234567890", []byte("account")); err != nil || tokenized != want {
+		t.Errorf("TokenizeWithTweak with the fixed tweak = %s (%v), expected %s", tokenized, err, want)
+	}
+	if _, err := fixed.TokenizeWithTweak("1234567890", []byte("other")); err == nil {
+		t.Error("Expected TokenizeWithTweak to reject a tweak other than the key's fixed tweak")
+	}
+	if _, err := fixed.DetokenizeWithTweak(want, []byte("other"), ""); err == nil {
+		t.Error("Expected DetokenizeWithTweak to reject a tweak other than the key's fixed tweak")
+	}
+}
@@ -0,0 +1,17 @@
+// Package tinkfpe provides Tink integration for Format-Preserving Encryption.
+// This file contains the self-identifying tokens of keys with a key hint.
+package tinkfpe
+
+import (
+	"fmt"
+)
+
+// readHint returns the n characters of tokenized at position, where tokens of
+// keys with a key hint carry it (see fpe.WithMarker).
+func readHint(tokenized string, position, n int) (string, error) {
+	runes := []rune(tokenized)
+	if len(runes) < position+n {
+		return "", fmt.Errorf("token of %d characters is too short to carry a key hint", len(runes))
+	}
+	return string(runes[position : position+n]), nil
+}
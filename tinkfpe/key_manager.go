@@ -6,11 +6,14 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/google/tink/go/core/registry"
 	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
 	"github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/vdparikh/fpe"
+	"github.com/vdparikh/fpe/proto/fpe_go_proto"
 	"github.com/vdparikh/fpe/subtle"
 	"google.golang.org/protobuf/proto"
 )
@@ -18,6 +21,12 @@ import (
 const (
 	// FPEKeyTypeURL is the type URL for FPE FF1 keys in Tink's registry.
 	FPEKeyTypeURL = "type.googleapis.com/google.crypto.tink.FpeFf1Key"
+
+	// FPEFF31KeyTypeURL is the type URL for FPE FF3-1 keys in Tink's registry.
+	FPEFF31KeyTypeURL = "type.googleapis.com/google.crypto.tink.FpeFf31Key"
+
+	// keyVersion is the version of FpeFf1Key and FpeFf31Key this package writes and reads.
+	keyVersion = 0
 )
 
 // KeyManager implements registry.KeyManager for FPE keys.
@@ -26,38 +35,65 @@ type KeyManager struct {
 	typeURL string
 }
 
-// NewKeyManager creates a new FPE key manager.
+// NewKeyManager creates a new FPE key manager for FF1 keys.
 func NewKeyManager() *KeyManager {
 	return &KeyManager{
 		typeURL: FPEKeyTypeURL,
 	}
 }
 
-// Primitive creates an FPE primitive from the given serialized key.
-func (km *KeyManager) Primitive(serializedKey []byte) (interface{}, error) {
-	// Parse the serialized key
-	// For now, we'll extract the key value directly
-	// In a full implementation, this would parse the protobuf key format
-	keyLen := len(serializedKey)
+// NewFF31KeyManager creates a new FPE key manager for FF3-1 keys.
+// Register it alongside NewKeyManager() to use keysets created from FF31KeyTemplate().
+func NewFF31KeyManager() *KeyManager {
+	return &KeyManager{
+		typeURL: FPEFF31KeyTypeURL,
+	}
+}
 
-	// Validate key size: must be 16, 24, or 32 bytes (AES-128, AES-192, AES-256)
-	if keyLen < 16 {
-		return nil, fmt.Errorf("key too short: %d bytes (minimum 16)", keyLen)
+// Primitive creates an FPE primitive from the given serialized key, an
+// FpeFf1Key or FpeFf31Key. The version and parameters of the key are
+// validated. Keys serialized by earlier releases as the raw AES key are
+// rejected; convert their keysets with MigrateLegacyKeyset. Use New, NewV2 or
+// NewBytes to get a usable primitive from a keyset.
+func (km *KeyManager) Primitive(serializedKey []byte) (interface{}, error) {
+	version, params, keyValue, err := km.parseKey(serializedKey)
+	if err != nil {
+		return nil, err
 	}
-	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
-		return nil, fmt.Errorf("invalid key size: %d bytes (must be 16, 24, or 32)", keyLen)
+	if version != keyVersion {
+		return nil, fmt.Errorf("unsupported key version: %d (expected %d)", version, keyVersion)
 	}
-
-	// Create FF1 instance from subtle package
-	// Note: In a real implementation, we'd parse the key format and extract tweak
-	ff1, err := subtle.NewFF1(serializedKey, nil)
+	if err := validateKeySize(len(keyValue)); err != nil {
+		return nil, err
+	}
+	options, err := km.validateParams(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create FF1: %w", err)
+		return nil, err
 	}
 
-	// Return the FF1 instance wrapped in a primitive interface
-	// The factory will handle wrapping this in the FPE interface
-	return ff1, nil
+	key := &keyPrimitive{
+		options: options,
+		hint:    params.GetKeyHint(),
+		fixed:   params.GetTweakPolicy() == fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED,
+		tweak:   params.GetTweak(),
+	}
+
+	// Without a fixed tweak the factory rebinds the cipher to the caller's
+	// tweak, so a zero tweak is sufficient here
+	if km.typeURL == FPEFF31KeyTypeURL {
+		tweak := key.tweak
+		if !key.fixed {
+			tweak = make([]byte, subtle.FF31TweakSize)
+		}
+		if key.cipher, err = subtle.NewFF31(keyValue, tweak); err != nil {
+			return nil, fmt.Errorf("failed to create FF3-1: %w", err)
+		}
+		return key, nil
+	}
+	if key.cipher, err = subtle.NewFF1(keyValue, key.tweak); err != nil {
+		return nil, fmt.Errorf("failed to create FF1: %w", err)
+	}
+	return key, nil
 }
 
 // DoesSupport returns true if this KeyManager supports the given key type URL.
@@ -70,53 +106,193 @@ func (km *KeyManager) TypeURL() string {
 	return km.typeURL
 }
 
-// NewKey generates a new key according to the given key template.
-func (km *KeyManager) NewKey(serializedKeyTemplate []byte) (proto.Message, error) {
-	// Generate a new random key
-	// For FPE, we need at least 16 bytes (AES-128), preferably 32 bytes (AES-256)
-	keySize := 32 // Default to AES-256
+// NewKey generates a new FpeFf1Key or FpeFf31Key from the given serialized
+// FpeFf1KeyFormat or FpeFf31KeyFormat. An empty format gives an AES-256 key
+// without parameters.
+func (km *KeyManager) NewKey(serializedKeyFormat []byte) (proto.Message, error) {
+	keySize, params, err := km.parseKeyFormat(serializedKeyFormat)
+	if err != nil {
+		return nil, err
+	}
 
-	// In a full implementation, we'd parse the template to get key size
-	key := make([]byte, keySize)
-	if _, err := rand.Read(key); err != nil {
+	keyValue := make([]byte, keySize)
+	if _, err := rand.Read(keyValue); err != nil {
 		return nil, fmt.Errorf("failed to generate random key: %w", err)
 	}
 
-	// In a full implementation, this would return a proper protobuf message
-	// For now, we return nil as a placeholder - the actual implementation would
-	// create and return a FpeFf1Key protobuf message
-	// This is a simplified version for demonstration
-	return nil, fmt.Errorf("NewKey not fully implemented - use NewKeyData instead")
+	if km.typeURL == FPEFF31KeyTypeURL {
+		return &fpe_go_proto.FpeFf31Key{Version: keyVersion, Params: params, KeyValue: keyValue}, nil
+	}
+	return &fpe_go_proto.FpeFf1Key{Version: keyVersion, Params: params, KeyValue: keyValue}, nil
 }
 
-// NewKeyData creates a new KeyData from the given key template.
-func (km *KeyManager) NewKeyData(serializedKeyTemplate []byte) (*tink_go_proto.KeyData, error) {
-	// Parse the template to get key size
-	keySize := 32 // Default to AES-256
-	if len(serializedKeyTemplate) > 0 {
-		// Template value contains the key size as a single byte
-		keySize = int(serializedKeyTemplate[0])
-		// Validate key size
-		if keySize != 16 && keySize != 24 && keySize != 32 {
-			return nil, fmt.Errorf("invalid key size in template: %d bytes (must be 16, 24, or 32)", keySize)
-		}
+// NewKeyData creates a new KeyData from the given serialized key format, as
+// NewKey does.
+func (km *KeyManager) NewKeyData(serializedKeyFormat []byte) (*tink_go_proto.KeyData, error) {
+	key, err := km.NewKey(serializedKeyFormat)
+	if err != nil {
+		return nil, err
 	}
-
-	// Generate a new random key
-	key := make([]byte, keySize)
-	if _, err := rand.Read(key); err != nil {
-		return nil, fmt.Errorf("failed to generate random key: %w", err)
+	serializedKey, err := proto.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize key: %w", err)
 	}
-
-	// Return a KeyData protobuf message
-	// SYMMETRIC = 2
 	return &tink_go_proto.KeyData{
 		TypeUrl:         km.typeURL,
-		Value:           key,
-		KeyMaterialType: 2, // SYMMETRIC
+		Value:           serializedKey,
+		KeyMaterialType: tink_go_proto.KeyData_SYMMETRIC,
 	}, nil
 }
 
+// algorithm returns the algorithm of the keys managed by km.
+func (km *KeyManager) algorithm() fpe_go_proto.FpeAlgorithm {
+	if km.typeURL == FPEFF31KeyTypeURL {
+		return fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1
+	}
+	return fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1
+}
+
+// parseKey returns the version, parameters and AES key of serializedKey.
+func (km *KeyManager) parseKey(serializedKey []byte) (uint32, *fpe_go_proto.FpeParams, []byte, error) {
+	var key interface {
+		proto.Message
+		GetVersion() uint32
+		GetParams() *fpe_go_proto.FpeParams
+		GetKeyValue() []byte
+	} = new(fpe_go_proto.FpeFf1Key)
+	if km.typeURL == FPEFF31KeyTypeURL {
+		key = new(fpe_go_proto.FpeFf31Key)
+	}
+	err := proto.Unmarshal(serializedKey, key)
+	if err == nil && len(key.ProtoReflect().GetUnknown()) > 0 {
+		err = fmt.Errorf("unknown fields")
+	}
+	if err == nil && key.GetParams() == nil {
+		err = fmt.Errorf("no key parameters")
+	}
+	if err != nil {
+		if validateKeySize(len(serializedKey)) == nil {
+			return 0, nil, nil, fmt.Errorf("invalid serialized key: not a valid key of type %s (raw keys of earlier releases need MigrateLegacyKeyset): %w", km.typeURL, err)
+		}
+		return 0, nil, nil, fmt.Errorf("invalid serialized key: not a valid key of type %s: %w", km.typeURL, err)
+	}
+	return key.GetVersion(), key.GetParams(), key.GetKeyValue(), nil
+}
+
+// parseKeyFormat returns the key size and parameters of serializedKeyFormat,
+// filling in the defaults.
+func (km *KeyManager) parseKeyFormat(serializedKeyFormat []byte) (int, *fpe_go_proto.FpeParams, error) {
+	var format interface {
+		proto.Message
+		GetVersion() uint32
+		GetParams() *fpe_go_proto.FpeParams
+		GetKeySize() uint32
+	} = new(fpe_go_proto.FpeFf1KeyFormat)
+	if km.typeURL == FPEFF31KeyTypeURL {
+		format = new(fpe_go_proto.FpeFf31KeyFormat)
+	}
+	// Earlier releases used a single key size byte, which is not a valid format
+	if len(serializedKeyFormat) == 1 {
+		return 0, nil, fmt.Errorf("invalid key format: key size byte templates of earlier releases are no longer supported; use KeyTemplateAES128, KeyTemplateAES192, KeyTemplateAES256 or KeyTemplateWithParams")
+	}
+	if err := proto.Unmarshal(serializedKeyFormat, format); err != nil {
+		return 0, nil, fmt.Errorf("invalid key format: %w", err)
+	}
+	if format.GetVersion() != keyVersion {
+		return 0, nil, fmt.Errorf("unsupported key format version: %d (expected %d)", format.GetVersion(), keyVersion)
+	}
+
+	keySize := int(format.GetKeySize())
+	if keySize == 0 {
+		keySize = 32 // Default to AES-256
+	}
+	if err := validateKeySize(keySize); err != nil {
+		return 0, nil, err
+	}
+
+	params := &fpe_go_proto.FpeParams{Algorithm: km.algorithm()}
+	if format.GetParams() != nil {
+		params = proto.Clone(format.GetParams()).(*fpe_go_proto.FpeParams)
+		if params.Algorithm == fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_UNSPECIFIED {
+			params.Algorithm = km.algorithm()
+		}
+	}
+	if _, err := km.validateParams(params); err != nil {
+		return 0, nil, err
+	}
+	return keySize, params, nil
+}
+
+// validateParams checks params for keys of km and returns the options they fix.
+func (km *KeyManager) validateParams(params *fpe_go_proto.FpeParams) ([]fpe.Option, error) {
+	if params.GetAlgorithm() != km.algorithm() {
+		return nil, fmt.Errorf("key algorithm %v does not match key type %s", params.GetAlgorithm(), km.typeURL)
+	}
+
+	var options []fpe.Option
+	switch {
+	case params.GetAlphabet() != "" && params.GetFormat() != "":
+		return nil, fmt.Errorf("key parameters cannot set both an alphabet and a format")
+	case params.GetAlphabet() != "":
+		alphabet, err := fpe.NewAlphabet(params.GetAlphabet())
+		if err != nil {
+			return nil, fmt.Errorf("invalid key alphabet: %w", err)
+		}
+		options = append(options, fpe.WithAlphabet(alphabet))
+	case params.GetFormat() != "":
+		format, err := fpe.ParseFormat(params.GetFormat())
+		if err != nil {
+			return nil, fmt.Errorf("invalid key format template: %w", err)
+		}
+		options = append(options, fpe.WithFormat(format))
+	}
+
+	switch params.GetTweakPolicy() {
+	case fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_CALLER:
+		if len(params.GetTweak()) > 0 {
+			return nil, fmt.Errorf("key parameters can only set a tweak with FPE_TWEAK_POLICY_FIXED")
+		}
+	case fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED:
+		if km.typeURL == FPEFF31KeyTypeURL && len(params.GetTweak()) != subtle.FF31TweakSize {
+			return nil, fmt.Errorf("invalid fixed tweak size: %d bytes (FF3-1 requires %d)", len(params.GetTweak()), subtle.FF31TweakSize)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tweak policy: %v", params.GetTweakPolicy())
+	}
+
+	if hint := params.GetKeyHint(); hint != nil {
+		if hint.GetValue() == "" || !utf8.ValidString(hint.GetValue()) || !utf8.ValidString(hint.GetFill()) {
+			return nil, fmt.Errorf("key hint must be non-empty UTF-8 text")
+		}
+		if utf8.RuneCountInString(hint.GetFill()) != utf8.RuneCountInString(hint.GetValue()) {
+			return nil, fmt.Errorf("key hint %q needs a fill of the same length, got %q", hint.GetValue(), hint.GetFill())
+		}
+	}
+	return options, nil
+}
+
+// validateKeySize checks that size is an AES key size.
+func validateKeySize(size int) error {
+	if size != 16 && size != 24 && size != 32 {
+		return fmt.Errorf("invalid key size: %d bytes (must be 16, 24, or 32)", size)
+	}
+	return nil
+}
+
+// keyPrimitive is the primitive KeyManager creates for a key: its cipher and
+// what the key's parameters fix. New, NewV2 and NewBytes bind it to a tweak.
+type keyPrimitive struct {
+	cipher  subtle.TweakableCipher
+	options []fpe.Option
+
+	// fixed is set for FPE_TWEAK_POLICY_FIXED keys, whose tweak is always tweak.
+	fixed bool
+	tweak []byte
+
+	// hint is the key hint written into tokens, or nil.
+	hint *fpe_go_proto.FpeKeyHint
+}
+
 // Verify that KeyManager implements registry.KeyManager
 var _ registry.KeyManager = (*KeyManager)(nil)
 
@@ -133,28 +309,84 @@ func KeyTemplate() *tink_go_proto.KeyTemplate {
 
 // KeyTemplateAES128 creates a key template for FPE FF1 with AES-128 (16 bytes).
 func KeyTemplateAES128() *tink_go_proto.KeyTemplate {
-	return &tink_go_proto.KeyTemplate{
-		TypeUrl:          FPEKeyTypeURL,
-		Value:            []byte{16}, // Key size: 16 bytes
-		OutputPrefixType: tink_go_proto.OutputPrefixType_RAW,
-	}
+	return keyTemplate(FPEKeyTypeURL, 16, nil)
 }
 
 // KeyTemplateAES192 creates a key template for FPE FF1 with AES-192 (24 bytes).
 func KeyTemplateAES192() *tink_go_proto.KeyTemplate {
-	return &tink_go_proto.KeyTemplate{
-		TypeUrl:          FPEKeyTypeURL,
-		Value:            []byte{24}, // Key size: 24 bytes
-		OutputPrefixType: tink_go_proto.OutputPrefixType_RAW,
-	}
+	return keyTemplate(FPEKeyTypeURL, 24, nil)
 }
 
 // KeyTemplateAES256 creates a key template for FPE FF1 with AES-256 (32 bytes).
 // This is the recommended template for maximum security.
 func KeyTemplateAES256() *tink_go_proto.KeyTemplate {
+	return keyTemplate(FPEKeyTypeURL, 32, nil)
+}
+
+// FF31KeyTemplate creates a key template for FPE FF3-1 keys with AES-256 (32 bytes).
+// Keys generated from this template require the FF3-1 KeyManager:
+//
+//	registry.RegisterKeyManager(tinkfpe.NewFF31KeyManager())
+//	handle, err := keyset.NewHandle(tinkfpe.FF31KeyTemplate())
+func FF31KeyTemplate() *tink_go_proto.KeyTemplate {
+	return FF31KeyTemplateAES256()
+}
+
+// FF31KeyTemplateAES128 creates a key template for FPE FF3-1 with AES-128 (16 bytes).
+func FF31KeyTemplateAES128() *tink_go_proto.KeyTemplate {
+	return keyTemplate(FPEFF31KeyTypeURL, 16, nil)
+}
+
+// FF31KeyTemplateAES192 creates a key template for FPE FF3-1 with AES-192 (24 bytes).
+func FF31KeyTemplateAES192() *tink_go_proto.KeyTemplate {
+	return keyTemplate(FPEFF31KeyTypeURL, 24, nil)
+}
+
+// FF31KeyTemplateAES256 creates a key template for FPE FF3-1 with AES-256 (32 bytes).
+func FF31KeyTemplateAES256() *tink_go_proto.KeyTemplate {
+	return keyTemplate(FPEFF31KeyTypeURL, 32, nil)
+}
+
+// KeyTemplateWithParams creates a key template for keys of keySize bytes (16,
+// 24 or 32) whose parameters fix the algorithm, alphabet or format, tweak
+// policy and key hint. It creates FF3-1 keys if params.Algorithm is FPE_ALGORITHM_FF3_1 and
+// FF1 keys otherwise.
+//
+// A key hint takes over data characters of every value, so its position must
+// be a constant "spare" character of the format, such as the leading zero of a
+// zero-padded account number, that every value holds as the hint's fill.
+// Tokenize rejects values that do not hold the fill there.
+//
+// Example:
+//
+//	template, err := tinkfpe.KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+//		Format: "###-##-####",
+//	})
+//	handle, err := keyset.NewHandle(template)
+func KeyTemplateWithParams(keySize int, params *fpe_go_proto.FpeParams) (*tink_go_proto.KeyTemplate, error) {
+	km := NewKeyManager()
+	if params.GetAlgorithm() == fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1 {
+		km = NewFF31KeyManager()
+	}
+	template := keyTemplate(km.typeURL, keySize, params)
+	if _, _, err := km.parseKeyFormat(template.Value); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// keyTemplate creates a template for keys of typeURL with the given key size
+// and parameters.
+func keyTemplate(typeURL string, keySize int, params *fpe_go_proto.FpeParams) *tink_go_proto.KeyTemplate {
+	var format proto.Message = &fpe_go_proto.FpeFf1KeyFormat{Params: params, KeySize: uint32(keySize)}
+	if typeURL == FPEFF31KeyTypeURL {
+		format = &fpe_go_proto.FpeFf31KeyFormat{Params: params, KeySize: uint32(keySize)}
+	}
+	// Marshaling a well-formed message does not fail
+	serializedFormat, _ := proto.Marshal(format)
 	return &tink_go_proto.KeyTemplate{
-		TypeUrl:          FPEKeyTypeURL,
-		Value:            []byte{32}, // Key size: 32 bytes
+		TypeUrl:          typeURL,
+		Value:            serializedFormat,
 		OutputPrefixType: tink_go_proto.OutputPrefixType_RAW,
 	}
 }
@@ -178,9 +410,16 @@ func KeyTemplateAES256() *tink_go_proto.KeyTemplate {
 // the keyset before storing it using keyset.Write() with an AEAD.
 func NewKeysetHandleFromKey(key []byte) (*keyset.Handle, error) {
 	// Validate key size
-	keyLen := len(key)
-	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
-		return nil, fmt.Errorf("invalid key size: %d bytes (must be 16, 24, or 32)", keyLen)
+	if err := validateKeySize(len(key)); err != nil {
+		return nil, err
+	}
+	serializedKey, err := proto.Marshal(&fpe_go_proto.FpeFf1Key{
+		Version:  keyVersion,
+		Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1},
+		KeyValue: key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize key: %w", err)
 	}
 
 	// Generate a unique key ID
@@ -193,8 +432,8 @@ func NewKeysetHandleFromKey(key []byte) (*keyset.Handle, error) {
 	// Create KeyData structure
 	keyData := &tink_go_proto.KeyData{
 		TypeUrl:         FPEKeyTypeURL,
-		Value:           key,
-		KeyMaterialType: 2, // SYMMETRIC
+		Value:           serializedKey,
+		KeyMaterialType: tink_go_proto.KeyData_SYMMETRIC,
 	}
 
 	// Create a keyset key
@@ -215,3 +454,50 @@ func NewKeysetHandleFromKey(key []byte) (*keyset.Handle, error) {
 	buf := &keyset.MemReaderWriter{Keyset: ks}
 	return insecurecleartextkeyset.Read(buf)
 }
+
+// MigrateLegacyKeyset converts a keyset written by earlier releases, whose FF1
+// and FF3-1 keys hold the raw AES key, to FpeFf1Key and FpeFf31Key messages
+// without parameters. Every FF1 and FF3-1 key of handle must be a raw key, so
+// migrate each keyset once, as a whole, and write the result back with
+// keyset.Write and a master AEAD. Other keys are copied unchanged.
+//
+// Example:
+//
+//	legacy, err := keyset.Read(keyset.NewBinaryReader(file), masterAEAD)
+//	handle, err := tinkfpe.MigrateLegacyKeyset(legacy)
+//	err = handle.Write(keyset.NewBinaryWriter(out), masterAEAD)
+func MigrateLegacyKeyset(handle *keyset.Handle) (*keyset.Handle, error) {
+	if handle == nil {
+		return nil, fmt.Errorf("keyset handle cannot be nil")
+	}
+	ks := proto.Clone(insecurecleartextkeyset.KeysetMaterial(handle)).(*tink_go_proto.Keyset)
+	for _, key := range ks.Key {
+		keyData := key.GetKeyData()
+		var migrated proto.Message
+		switch keyData.GetTypeUrl() {
+		case FPEKeyTypeURL:
+			migrated = &fpe_go_proto.FpeFf1Key{
+				Version:  keyVersion,
+				Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1},
+				KeyValue: keyData.GetValue(),
+			}
+		case FPEFF31KeyTypeURL:
+			migrated = &fpe_go_proto.FpeFf31Key{
+				Version:  keyVersion,
+				Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1},
+				KeyValue: keyData.GetValue(),
+			}
+		default:
+			continue
+		}
+		if err := validateKeySize(len(keyData.GetValue())); err != nil {
+			return nil, fmt.Errorf("key %d is not a raw key of an earlier release: %w", key.GetKeyId(), err)
+		}
+		serializedKey, err := proto.Marshal(migrated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize key: %w", err)
+		}
+		keyData.Value = serializedKey
+	}
+	return insecurecleartextkeyset.Read(&keyset.MemReaderWriter{Keyset: ks})
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
 	"github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/vdparikh/fpe/proto/fpe_go_proto"
+	"google.golang.org/protobuf/proto"
 )
 
 // TestKeyManagerWithNISTVectors tests the KeyManager using official NIST SP 800-38G test vectors
@@ -150,12 +152,37 @@ func testKeyManagerWithWycheproofVector(t *testing.T, keyManager *KeyManager, te
 	}
 }
 
-// createKeysetHandleFromKey creates a keyset handle from raw key bytes
+// createKeysetHandleFromKey creates a keyset handle from raw FF1 key bytes
 func createKeysetHandleFromKey(key []byte) (*keyset.Handle, error) {
+	return createKeysetHandleWithType(FPEKeyTypeURL, key)
+}
+
+// createKeysetHandleWithType creates a keyset handle from raw key bytes for the given key type
+func createKeysetHandleWithType(typeURL string, key []byte) (*keyset.Handle, error) {
+	var message proto.Message = &fpe_go_proto.FpeFf1Key{
+		Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1},
+		KeyValue: key,
+	}
+	if typeURL == FPEFF31KeyTypeURL {
+		message = &fpe_go_proto.FpeFf31Key{
+			Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1},
+			KeyValue: key,
+		}
+	}
+	serializedKey, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return createKeysetHandleWithKeyData(typeURL, serializedKey)
+}
+
+// createKeysetHandleWithKeyData creates a keyset handle holding one key of the
+// given type with the given serialized key
+func createKeysetHandleWithKeyData(typeURL string, serializedKey []byte) (*keyset.Handle, error) {
 	keyData := &tink_go_proto.KeyData{
-		TypeUrl:         FPEKeyTypeURL,
-		Value:           key,
-		KeyMaterialType: 2, // SYMMETRIC
+		TypeUrl:         typeURL,
+		Value:           serializedKey,
+		KeyMaterialType: tink_go_proto.KeyData_SYMMETRIC,
 	}
 
 	keysetKey := &tink_go_proto.Keyset_Key{
@@ -199,8 +226,8 @@ func serializeKeyset(handle *keyset.Handle) ([]byte, error) {
 
 // deserializeKeyset deserializes keyset bytes back to a handle
 func deserializeKeyset(keyBytes []byte) (*keyset.Handle, error) {
-	// Recreate the keyset from the key bytes
-	return createKeysetHandleFromKey(keyBytes)
+	// Recreate the keyset from the serialized key
+	return createKeysetHandleWithKeyData(FPEKeyTypeURL, keyBytes)
 }
 
 // TestKeyManagerPrimitive tests that KeyManager.Primitive() works correctly
@@ -213,7 +240,14 @@ func TestKeyManagerPrimitive(t *testing.T) {
 		key[i] = byte(i)
 	}
 
-	primitive, err := keyManager.Primitive(key)
+	serializedKey, err := proto.Marshal(&fpe_go_proto.FpeFf1Key{
+		Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1},
+		KeyValue: key,
+	})
+	if err != nil {
+		t.Fatalf("Failed to serialize key: %v", err)
+	}
+	primitive, err := keyManager.Primitive(serializedKey)
 	if err != nil {
 		t.Fatalf("KeyManager.Primitive() failed: %v", err)
 	}
@@ -251,3 +285,217 @@ func TestKeyManagerTypeURL(t *testing.T) {
 		t.Errorf("Expected TypeURL %s, got %s", FPEKeyTypeURL, keyManager.TypeURL())
 	}
 }
+
+// TestKeyManagerKeyFormats verifies that NewKeyData generates FpeFf1Key and
+// FpeFf31Key messages that carry the template's parameters.
+func TestKeyManagerKeyFormats(t *testing.T) {
+	testCases := []struct {
+		name      string
+		km        *KeyManager
+		template  *tink_go_proto.KeyTemplate
+		algorithm fpe_go_proto.FpeAlgorithm
+		keySize   int
+	}{
+		{"FF1", NewKeyManager(), KeyTemplateAES128(), fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1, 16},
+		{"FF3-1", NewFF31KeyManager(), FF31KeyTemplate(), fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1, 32},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyData, err := tc.km.NewKeyData(tc.template.Value)
+			if err != nil {
+				t.Fatalf("NewKeyData failed: %v", err)
+			}
+			version, params, keyValue, err := tc.km.parseKey(keyData.Value)
+			if err != nil {
+				t.Fatalf("parseKey failed: %v", err)
+			}
+			if version != keyVersion || params.GetAlgorithm() != tc.algorithm || len(keyValue) != tc.keySize {
+				t.Errorf("Unexpected key: version %d, algorithm %v, %d-byte key", version, params.GetAlgorithm(), len(keyValue))
+			}
+			if _, err := tc.km.Primitive(keyData.Value); err != nil {
+				t.Errorf("Primitive failed: %v", err)
+			}
+		})
+	}
+
+	// Raw keys written by earlier releases need MigrateLegacyKeyset
+	if _, err := NewKeyManager().Primitive(make([]byte, 32)); err == nil {
+		t.Error("Expected Primitive to reject a raw legacy key")
+	}
+
+	// Truncated keys are not mistaken for raw keys
+	serializedKey, err := proto.Marshal(&fpe_go_proto.FpeFf1Key{
+		Params:   &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1},
+		KeyValue: make([]byte, 32),
+	})
+	if err != nil {
+		t.Fatalf("Failed to serialize key: %v", err)
+	}
+	if _, err := NewKeyManager().Primitive(serializedKey[:32]); err == nil {
+		t.Error("Expected Primitive to reject a truncated key")
+	}
+
+	// Key size byte templates of earlier releases are rejected
+	if _, err := NewKeyManager().NewKeyData([]byte{32}); err == nil {
+		t.Error("Expected NewKeyData to reject a key size byte template")
+	}
+}
+
+// TestMigrateLegacyKeyset verifies that raw keys of earlier releases are
+// rejected until their keyset is migrated, and that migrated keys give the
+// same tokens.
+func TestMigrateLegacyKeyset(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	if _, err := getOrRegisterFF31KeyManager(); err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	for _, typeURL := range []string{FPEKeyTypeURL, FPEFF31KeyTypeURL} {
+		t.Run(typeURL, func(t *testing.T) {
+			legacy, err := createKeysetHandleWithKeyData(typeURL, key)
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+			if _, err := New(legacy, []byte("tweak77")); err == nil {
+				t.Error("Expected New to reject a raw legacy key")
+			}
+
+			handle, err := MigrateLegacyKeyset(legacy)
+			if err != nil {
+				t.Fatalf("MigrateLegacyKeyset failed: %v", err)
+			}
+			primitive, err := New(handle, []byte("tweak77"))
+			if err != nil {
+				t.Fatalf("New failed after migration: %v", err)
+			}
+			expected, err := createKeysetHandleWithType(typeURL, key)
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+			reference, err := New(expected, []byte("tweak77"))
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			tokenized, err := primitive.Tokenize("123-45-6789")
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if want, err := reference.Tokenize("123-45-6789"); err != nil || tokenized != want {
+				t.Errorf("Migrated key gave %s, expected %s (%v)", tokenized, want, err)
+			}
+
+			// Migrating twice fails: the keys are no longer raw
+			if _, err := MigrateLegacyKeyset(handle); err == nil {
+				t.Error("Expected MigrateLegacyKeyset to reject a migrated keyset")
+			}
+		})
+	}
+}
+
+// TestKeyManagerRejectsInvalidKeys verifies the validation of key versions and
+// parameters.
+func TestKeyManagerRejectsInvalidKeys(t *testing.T) {
+	km := NewKeyManager()
+	ff1 := fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF1
+	testCases := []struct {
+		name string
+		key  *fpe_go_proto.FpeFf1Key
+	}{
+		{"version", &fpe_go_proto.FpeFf1Key{Version: 1, Params: &fpe_go_proto.FpeParams{Algorithm: ff1}}},
+		{"algorithm", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1}}},
+		{"alphabet and format", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: ff1, Alphabet: "0123456789", Format: "###"}}},
+		{"alphabet", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: ff1, Alphabet: "0"}}},
+		{"format", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: ff1, Format: "[#"}}},
+		{"caller tweak", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: ff1, Tweak: []byte("tweak")}}},
+		{"key hint", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: ff1, KeyHint: &fpe_go_proto.FpeKeyHint{}}}},
+		{"key hint fill", &fpe_go_proto.FpeFf1Key{Params: &fpe_go_proto.FpeParams{Algorithm: ff1, KeyHint: &fpe_go_proto.FpeKeyHint{Value: "7"}}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.key.KeyValue = make([]byte, 32)
+			serializedKey, err := proto.Marshal(tc.key)
+			if err != nil {
+				t.Fatalf("Failed to serialize key: %v", err)
+			}
+			if _, err := km.Primitive(serializedKey); err == nil {
+				t.Error("Expected Primitive to reject the key")
+			}
+		})
+	}
+
+	if _, err := KeyTemplateWithParams(20, nil); err == nil {
+		t.Error("Expected KeyTemplateWithParams to reject a 20-byte key size")
+	}
+	if _, err := KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+		Algorithm:   fpe_go_proto.FpeAlgorithm_FPE_ALGORITHM_FF3_1,
+		TweakPolicy: fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED,
+		Tweak:       []byte("tweak"),
+	}); err == nil {
+		t.Error("Expected KeyTemplateWithParams to reject a fixed FF3-1 tweak that is not 7 bytes")
+	}
+}
+
+// TestKeyTemplateWithParams verifies that the format and fixed tweak of a key
+// apply to every primitive created from it.
+func TestKeyTemplateWithParams(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	template, err := KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+		Format:      "###-##-####",
+		TweakPolicy: fpe_go_proto.FpeTweakPolicy_FPE_TWEAK_POLICY_FIXED,
+		Tweak:       []byte("customer.ssn"),
+	})
+	if err != nil {
+		t.Fatalf("KeyTemplateWithParams failed: %v", err)
+	}
+	handle, err := keyset.NewHandle(template)
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+
+	primitive, err := NewV2(handle, nil)
+	if err != nil {
+		t.Fatalf("NewV2 failed: %v", err)
+	}
+	ssn := "123-45-6789"
+	tokenized, err := primitive.Tokenize(ssn)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if tokenized == ssn || len(tokenized) != len(ssn) || tokenized[3] != '-' || tokenized[6] != '-' {
+		t.Errorf("Bad token for %s: %s", ssn, tokenized)
+	}
+	detokenized, err := primitive.Detokenize(tokenized)
+	if err != nil {
+		t.Fatalf("Detokenize failed: %v", err)
+	}
+	if detokenized != ssn {
+		t.Errorf("Round-trip failed: %s -> %s -> %s", ssn, tokenized, detokenized)
+	}
+	if _, err := primitive.Tokenize("123456789"); err == nil {
+		t.Error("Expected the key's format to reject a value that does not match it")
+	}
+
+	// The fixed tweak is used whether the caller passes it or not
+	same, err := NewV2(handle, []byte("customer.ssn"))
+	if err != nil {
+		t.Fatalf("NewV2 with the fixed tweak failed: %v", err)
+	}
+	if token, err := same.Tokenize(ssn); err != nil || token != tokenized {
+		t.Errorf("Expected token %s with the fixed tweak, got %s (%v)", tokenized, token, err)
+	}
+	if _, err := NewV2(handle, []byte("other")); err == nil {
+		t.Error("Expected NewV2 to reject a tweak other than the key's fixed tweak")
+	}
+	if _, err := NewLegacy(handle, nil); err == nil {
+		t.Error("Expected NewLegacy to reject a key with a format")
+	}
+}
@@ -0,0 +1,178 @@
+// Package tinkfpe provides Tink integration for Format-Preserving Encryption.
+// This file contains the KeyManager for FPE keys wrapped by a key in a remote KMS.
+package tinkfpe
+
+import (
+	"fmt"
+
+	"github.com/google/tink/go/core/registry"
+	"github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/google/tink/go/tink"
+	"github.com/vdparikh/fpe/proto/fpe_go_proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// FPEKMSEnvelopeKeyTypeURL is the type URL for FPE keys wrapped by a key
+	// encryption key (KEK) in a remote KMS.
+	FPEKMSEnvelopeKeyTypeURL = "type.googleapis.com/google.crypto.tink.FpeKmsEnvelopeKey"
+
+	// kmsEnvelopeKeyVersion is the version of FpeKmsEnvelopeKey this package writes and reads.
+	kmsEnvelopeKeyVersion = 0
+)
+
+// KMSEnvelopeKeyManager implements registry.KeyManager for FPE keys whose key
+// material is REMOTE: the FF1 or FF3-1 key is stored in the keyset encrypted
+// with a KEK that never leaves the KMS. The KEK is resolved through the KMS
+// clients registered with registry.RegisterKMSClient, whose tink.AEAD unwraps
+// the key each time a primitive is created.
+type KMSEnvelopeKeyManager struct{}
+
+// NewKMSEnvelopeKeyManager creates a key manager for KMS envelope keys.
+// Register it, together with the manager of the wrapped key type and a KMS
+// client for the KEK URI, to use keysets created from KMSEnvelopeKeyTemplate():
+//
+//	registry.RegisterKMSClient(kmsClient)
+//	registry.RegisterKeyManager(tinkfpe.NewKeyManager())
+//	registry.RegisterKeyManager(tinkfpe.NewKMSEnvelopeKeyManager())
+//	handle, err := keyset.NewHandle(tinkfpe.KMSEnvelopeKeyTemplate(kekURI, tinkfpe.KeyTemplate()))
+func NewKMSEnvelopeKeyManager() *KMSEnvelopeKeyManager {
+	return &KMSEnvelopeKeyManager{}
+}
+
+// Primitive unwraps the key with its KEK and returns the primitive of the
+// wrapped key, as created by the KeyManager registered for its type.
+func (km *KMSEnvelopeKeyManager) Primitive(serializedKey []byte) (interface{}, error) {
+	key := new(fpe_go_proto.FpeKmsEnvelopeKey)
+	if err := proto.Unmarshal(serializedKey, key); err != nil {
+		return nil, fmt.Errorf("invalid KMS envelope key: %w", err)
+	}
+	if key.GetVersion() != kmsEnvelopeKeyVersion {
+		return nil, fmt.Errorf("unsupported KMS envelope key version: %d", key.GetVersion())
+	}
+
+	kek, err := kekAEAD(key.GetParams().GetKekUri())
+	if err != nil {
+		return nil, err
+	}
+	serializedKeyData, err := kek.Decrypt(key.GetEncryptedKeyData(), []byte(FPEKMSEnvelopeKeyTypeURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key: %w", err)
+	}
+	keyData := new(tink_go_proto.KeyData)
+	if err := proto.Unmarshal(serializedKeyData, keyData); err != nil {
+		return nil, fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	if err := checkWrappedKeyType(keyData.GetTypeUrl()); err != nil {
+		return nil, err
+	}
+	return registry.PrimitiveFromKeyData(keyData)
+}
+
+// DoesSupport returns true if typeURL is FPEKMSEnvelopeKeyTypeURL.
+func (km *KMSEnvelopeKeyManager) DoesSupport(typeURL string) bool {
+	return typeURL == FPEKMSEnvelopeKeyTypeURL
+}
+
+// TypeURL returns FPEKMSEnvelopeKeyTypeURL.
+func (km *KMSEnvelopeKeyManager) TypeURL() string {
+	return FPEKMSEnvelopeKeyTypeURL
+}
+
+// NewKey generates a key from the template of the wrapped key in the given
+// serialized FpeKmsEnvelopeKeyFormat and wraps it with the KEK.
+func (km *KMSEnvelopeKeyManager) NewKey(serializedKeyFormat []byte) (proto.Message, error) {
+	format := new(fpe_go_proto.FpeKmsEnvelopeKeyFormat)
+	if err := proto.Unmarshal(serializedKeyFormat, format); err != nil {
+		return nil, fmt.Errorf("invalid KMS envelope key format: %w", err)
+	}
+	if err := checkWrappedKeyType(format.GetDekTemplate().GetTypeUrl()); err != nil {
+		return nil, err
+	}
+
+	kek, err := kekAEAD(format.GetKekUri())
+	if err != nil {
+		return nil, err
+	}
+	keyData, err := registry.NewKeyData(format.GetDekTemplate())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serializedKeyData, err := proto.Marshal(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize key: %w", err)
+	}
+	encrypted, err := kek.Encrypt(serializedKeyData, []byte(FPEKMSEnvelopeKeyTypeURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %w", err)
+	}
+
+	return &fpe_go_proto.FpeKmsEnvelopeKey{
+		Version:          kmsEnvelopeKeyVersion,
+		Params:           format,
+		EncryptedKeyData: encrypted,
+	}, nil
+}
+
+// NewKeyData generates a wrapped key as NewKey does and returns it as KeyData
+// with REMOTE key material.
+func (km *KMSEnvelopeKeyManager) NewKeyData(serializedKeyFormat []byte) (*tink_go_proto.KeyData, error) {
+	key, err := km.NewKey(serializedKeyFormat)
+	if err != nil {
+		return nil, err
+	}
+	serializedKey, err := proto.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize key: %w", err)
+	}
+	return &tink_go_proto.KeyData{
+		TypeUrl:         FPEKMSEnvelopeKeyTypeURL,
+		Value:           serializedKey,
+		KeyMaterialType: tink_go_proto.KeyData_REMOTE,
+	}, nil
+}
+
+// Verify that KMSEnvelopeKeyManager implements registry.KeyManager
+var _ registry.KeyManager = (*KMSEnvelopeKeyManager)(nil)
+
+// KMSEnvelopeKeyTemplate creates a key template for FPE keys generated from
+// dekTemplate (such as KeyTemplate() or FF31KeyTemplate()) and wrapped by the
+// KEK at kekURI. Keysets created from it hold no cleartext key material, so
+// they can be stored with keyset.Handle.WriteWithNoSecrets.
+func KMSEnvelopeKeyTemplate(kekURI string, dekTemplate *tink_go_proto.KeyTemplate) *tink_go_proto.KeyTemplate {
+	format := &fpe_go_proto.FpeKmsEnvelopeKeyFormat{
+		KekUri:      kekURI,
+		DekTemplate: dekTemplate,
+	}
+	// Marshaling a well-formed message does not fail; NewKey reports an empty format
+	serializedFormat, _ := proto.Marshal(format)
+	return &tink_go_proto.KeyTemplate{
+		TypeUrl:          FPEKMSEnvelopeKeyTypeURL,
+		Value:            serializedFormat,
+		OutputPrefixType: tink_go_proto.OutputPrefixType_RAW,
+	}
+}
+
+// kekAEAD returns the AEAD of the KEK at uri from the registered KMS clients.
+func kekAEAD(uri string) (tink.AEAD, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("KEK URI cannot be empty")
+	}
+	client, err := registry.GetKMSClient(uri)
+	if err != nil {
+		return nil, fmt.Errorf("no KMS client for KEK %q: %w", uri, err)
+	}
+	kek, err := client.GetAEAD(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AEAD for KEK %q: %w", uri, err)
+	}
+	return kek, nil
+}
+
+// checkWrappedKeyType checks that typeURL is an FF1 or FF3-1 key type.
+func checkWrappedKeyType(typeURL string) error {
+	if typeURL != FPEKeyTypeURL && typeURL != FPEFF31KeyTypeURL {
+		return fmt.Errorf("KMS envelope keys must wrap FF1 or FF3-1 keys, got %q", typeURL)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package tinkfpe
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/tink/go/core/registry"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/google/tink/go/tink"
+)
+
+// memAEAD is an in-memory AES-GCM AEAD that stands in for a KMS key.
+type memAEAD struct {
+	gcm cipher.AEAD
+}
+
+func newMemAEAD(t *testing.T) *memAEAD {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("Failed to create AES cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("Failed to create GCM: %v", err)
+	}
+	return &memAEAD{gcm: gcm}
+}
+
+func (a *memAEAD) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, a.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return a.gcm.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func (a *memAEAD) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < a.gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	n := a.gcm.NonceSize()
+	return a.gcm.Open(nil, ciphertext[:n], ciphertext[n:], associatedData)
+}
+
+// memKMSClient serves memAEAD keys for URIs with its prefix.
+type memKMSClient struct {
+	prefix string
+	keys   map[string]tink.AEAD
+}
+
+func (c *memKMSClient) Supported(keyURI string) bool {
+	return strings.HasPrefix(keyURI, c.prefix)
+}
+
+func (c *memKMSClient) GetAEAD(keyURI string) (tink.AEAD, error) {
+	kek, ok := c.keys[keyURI]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyURI)
+	}
+	return kek, nil
+}
+
+// registerKMSEnvelope registers the FPE key managers and a memKMSClient
+// holding one KEK, and returns the KEK URI.
+func registerKMSEnvelope(t *testing.T) string {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	if _, err := getOrRegisterFF31KeyManager(); err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+	if _, err := registry.GetKeyManager(FPEKMSEnvelopeKeyTypeURL); err != nil {
+		if err := registry.RegisterKeyManager(NewKMSEnvelopeKeyManager()); err != nil {
+			t.Fatalf("Failed to register KMS envelope KeyManager: %v", err)
+		}
+	}
+	uri := "mem-kms://" + t.Name() + "/kek"
+	registry.RegisterKMSClient(&memKMSClient{
+		prefix: uri,
+		keys:   map[string]tink.AEAD{uri: newMemAEAD(t)},
+	})
+	return uri
+}
+
+// TestNewWithEncryptedKeyset verifies that New accepts a keyset read with a
+// master AEAD and produces the same tokens as the original handle.
+func TestNewWithEncryptedKeyset(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+	handle, err := keyset.NewHandle(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+
+	master := newMemAEAD(t)
+	buf := new(bytes.Buffer)
+	if err := handle.Write(keyset.NewBinaryWriter(buf), master); err != nil {
+		t.Fatalf("Failed to write encrypted keyset: %v", err)
+	}
+	encrypted := buf.Bytes()
+
+	read, err := keyset.Read(keyset.NewBinaryReader(bytes.NewReader(encrypted)), master)
+	if err != nil {
+		t.Fatalf("Failed to read encrypted keyset: %v", err)
+	}
+	primitive, err := New(read, []byte("tweak"))
+	if err != nil {
+		t.Fatalf("New() failed for an encrypted keyset: %v", err)
+	}
+	original, err := New(handle, []byte("tweak"))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	plaintext := "4532-1234-5678-9010"
+	tokenized, err := primitive.Tokenize(plaintext)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	want, err := original.Tokenize(plaintext)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if tokenized != want {
+		t.Errorf("Encrypted keyset tokenized differently: %s vs %s", tokenized, want)
+	}
+
+	if _, err := keyset.Read(keyset.NewBinaryReader(bytes.NewReader(encrypted)), newMemAEAD(t)); err == nil {
+		t.Error("Expected reading the keyset with the wrong master key to fail")
+	}
+}
+
+// TestKMSEnvelopeKey verifies that keysets of KMS envelope keys hold no
+// cleartext key material, survive a round-trip through storage, and tokenize
+// with the wrapped key.
+func TestKMSEnvelopeKey(t *testing.T) {
+	kekURI := registerKMSEnvelope(t)
+
+	testCases := []struct {
+		name     string
+		template *tink_go_proto.KeyTemplate
+		tweak    []byte
+	}{
+		{"FF1", KeyTemplate(), []byte("tenant-1234|customer.ssn")},
+		{"FF3-1", FF31KeyTemplate(), []byte("tenant1")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handle, err := keyset.NewHandle(KMSEnvelopeKeyTemplate(kekURI, tc.template))
+			if err != nil {
+				t.Fatalf("Failed to create keyset handle: %v", err)
+			}
+
+			// No secrets, so the keyset can be stored in the clear
+			buf := &keyset.MemReaderWriter{}
+			if err := handle.WriteWithNoSecrets(buf); err != nil {
+				t.Fatalf("WriteWithNoSecrets failed: %v", err)
+			}
+			if got := buf.Keyset.Key[0].KeyData.KeyMaterialType; got != tink_go_proto.KeyData_REMOTE {
+				t.Errorf("Expected REMOTE key material, got %v", got)
+			}
+			read, err := keyset.ReadWithNoSecrets(buf)
+			if err != nil {
+				t.Fatalf("ReadWithNoSecrets failed: %v", err)
+			}
+
+			primitive, err := NewV2(read, tc.tweak)
+			if err != nil {
+				t.Fatalf("NewV2() failed: %v", err)
+			}
+			plaintext := "AB12-3456-7890"
+			tokenized, err := primitive.Tokenize(plaintext)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if tokenized == plaintext {
+				t.Errorf("Tokenize returned the plaintext")
+			}
+
+			again, err := NewV2(handle, tc.tweak)
+			if err != nil {
+				t.Fatalf("NewV2() failed: %v", err)
+			}
+			detokenized, err := again.Detokenize(tokenized)
+			if err != nil {
+				t.Fatalf("Detokenize failed: %v", err)
+			}
+			if detokenized != plaintext {
+				t.Errorf("Round-trip failed: expected %s, got %s", plaintext, detokenized)
+			}
+		})
+	}
+}
+
+// TestKMSEnvelopeKeyErrors verifies that unknown KEKs, tampered keys and
+// templates that do not wrap an FPE key are rejected.
+func TestKMSEnvelopeKeyErrors(t *testing.T) {
+	kekURI := registerKMSEnvelope(t)
+
+	if _, err := keyset.NewHandle(KMSEnvelopeKeyTemplate("unknown-kms://kek", KeyTemplate())); err == nil {
+		t.Error("Expected an error for a KEK without a registered KMS client")
+	}
+	if _, err := keyset.NewHandle(KMSEnvelopeKeyTemplate(kekURI, KMSEnvelopeKeyTemplate(kekURI, KeyTemplate()))); err == nil {
+		t.Error("Expected an error for an envelope key wrapping an envelope key")
+	}
+	if _, err := keyset.NewHandle(KMSEnvelopeKeyTemplate("", KeyTemplate())); err == nil {
+		t.Error("Expected an error for an empty KEK URI")
+	}
+
+	handle, err := keyset.NewHandle(KMSEnvelopeKeyTemplate(kekURI, KeyTemplate()))
+	if err != nil {
+		t.Fatalf("Failed to create keyset handle: %v", err)
+	}
+	buf := &keyset.MemReaderWriter{}
+	if err := handle.WriteWithNoSecrets(buf); err != nil {
+		t.Fatalf("WriteWithNoSecrets failed: %v", err)
+	}
+	keyData := buf.Keyset.Key[0].KeyData
+	keyData.Value[len(keyData.Value)-1] ^= 1
+	tampered, err := keyset.ReadWithNoSecrets(buf)
+	if err != nil {
+		t.Fatalf("ReadWithNoSecrets failed: %v", err)
+	}
+	if _, err := New(tampered, []byte("tweak")); err == nil {
+		t.Error("Expected New() to fail for a tampered wrapped key")
+	}
+}
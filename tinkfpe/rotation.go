@@ -0,0 +1,214 @@
+// Package tinkfpe provides Tink integration for Format-Preserving Encryption.
+// This file contains the rotation-aware primitive over every enabled key of a keyset.
+package tinkfpe
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/vdparikh/fpe"
+)
+
+// RotatingFPE is an fpe.TweakableFPE primitive for keysets that are rotated. Like
+// Tink's deterministic AEAD wrapper it tokenizes with the primary key, but it
+// can detokenize with any ENABLED key of the keyset, so tokens issued before a
+// rotation stay usable until their key is disabled.
+//
+// FPE tokens have no room for Tink's output prefix. Keys created with a key
+// hint (see KeyTemplateWithParams) make their tokens self-identifying, and
+// Detokenize finds the key from the hint. The hint replaces a constant spare
+// character of the format, so Tokenize rejects values that do not hold the
+// hint's fill there. Otherwise the caller names the key of a token by its key
+// ID (see DetokenizeWithKeyID and Retokenize).
+type RotatingFPE struct {
+	primary fpe.TweakableFPE
+	// primaryKeyID is the ID of the primary key
+	primaryKeyID uint32
+	// tokenizers holds the primitive of each enabled key by key ID
+	tokenizers map[uint32]fpe.TweakableFPE
+
+	// hints maps the key hints of the keyset to key IDs. All hints have
+	// hintLength characters at hintPosition.
+	hints        map[string]uint32
+	hintPosition int
+	hintLength   int
+	// unhinted is whether a key of the keyset has no key hint
+	unhinted bool
+}
+
+// NewRotating creates a rotation-aware v2 FPE primitive from a Tink keyset
+// handle. Every enabled key gets a primitive with the given tweak and options,
+// as NewV2 creates for the primary key, so the tweak can also be given per
+// call (see TokenizeWithTweak). Either every enabled key of the keyset has a
+// key hint or none has, and the key hints must have the same length and
+// position and be distinct.
+//
+// Example:
+//
+//	primitive, err := tinkfpe.NewRotating(handle, []byte("tweak"), fpe.WithAlphabet(fpe.AlphabetNumeric))
+//	token, err := primitive.Tokenize("123-45-6789")
+//
+//	// After rotating the keyset, migrate a token issued by an older key
+//	token, err = primitive.Retokenize(token, oldKeyID)
+func NewRotating(handle *keyset.Handle, tweak []byte, opts ...fpe.Option) (*RotatingFPE, error) {
+	if handle == nil {
+		return nil, fmt.Errorf("keyset handle cannot be nil")
+	}
+
+	primitives, err := handle.Primitives()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primitives from handle: %w", err)
+	}
+	if primitives.Primary == nil {
+		return nil, fmt.Errorf("no primary key found in keyset")
+	}
+
+	r := &RotatingFPE{
+		primaryKeyID: primitives.Primary.KeyID,
+		tokenizers:   make(map[uint32]fpe.TweakableFPE),
+		hints:        make(map[string]uint32),
+	}
+	for _, entries := range primitives.Entries {
+		for _, entry := range entries {
+			key, ok := entry.Primitive.(*keyPrimitive)
+			if !ok {
+				return nil, fmt.Errorf("key %d is not an FPE key: got primitive %T", entry.KeyID, entry.Primitive)
+			}
+			tokenizer, err := key.newTokenizer(tweak, opts)
+			if err != nil {
+				return nil, fmt.Errorf("key %d: %w", entry.KeyID, err)
+			}
+			r.tokenizers[entry.KeyID] = tokenizer
+			if err := r.addHint(key, entry.KeyID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	r.primary = r.tokenizers[r.primaryKeyID]
+	return r, nil
+}
+
+// addHint records the key hint of the key with the given ID, if it has one.
+func (r *RotatingFPE) addHint(key *keyPrimitive, keyID uint32) error {
+	if key.hint == nil {
+		if len(r.hints) > 0 {
+			return fmt.Errorf("key %d has no key hint: either every enabled key of a keyset has a key hint or none has", keyID)
+		}
+		r.unhinted = true
+		return nil
+	}
+	if r.unhinted {
+		return fmt.Errorf("key %d has a key hint: either every enabled key of a keyset has a key hint or none has", keyID)
+	}
+	value := key.hint.GetValue()
+	position, length := int(key.hint.GetPosition()), utf8.RuneCountInString(value)
+	if len(r.hints) == 0 {
+		r.hintPosition, r.hintLength = position, length
+	} else if position != r.hintPosition || length != r.hintLength {
+		return fmt.Errorf("key %d: key hints of a keyset must have the same length and position", keyID)
+	}
+	if other, ok := r.hints[value]; ok {
+		return fmt.Errorf("keys %d and %d have the same key hint %q", other, keyID, value)
+	}
+	r.hints[value] = keyID
+	return nil
+}
+
+// PrimaryKeyID returns the ID of the key Tokenize uses. Store it with tokens
+// whose key must be known later.
+func (r *RotatingFPE) PrimaryKeyID() uint32 {
+	return r.primaryKeyID
+}
+
+// KeyID returns the ID of the key that issued tokenized, found from its key
+// hint. It fails if the keys of the keyset have no key hints.
+func (r *RotatingFPE) KeyID(tokenized string) (uint32, error) {
+	if len(r.hints) == 0 {
+		return 0, fmt.Errorf("keys of the keyset have no key hints")
+	}
+	hint, err := readHint(tokenized, r.hintPosition, r.hintLength)
+	if err != nil {
+		return 0, err
+	}
+	keyID, ok := r.hints[hint]
+	if !ok {
+		return 0, fmt.Errorf("no enabled key with key hint %q in keyset", hint)
+	}
+	return keyID, nil
+}
+
+// Tokenize encrypts plaintext with the primary key.
+func (r *RotatingFPE) Tokenize(plaintext string) (string, error) {
+	return r.primary.Tokenize(plaintext)
+}
+
+// Detokenize decrypts a token issued by the enabled key named by its key hint
+// or, if the keys of the keyset have no key hints, by the primary key.
+func (r *RotatingFPE) Detokenize(tokenized string) (string, error) {
+	if len(r.hints) == 0 {
+		return r.primary.Detokenize(tokenized)
+	}
+	keyID, err := r.KeyID(tokenized)
+	if err != nil {
+		return "", err
+	}
+	return r.DetokenizeWithKeyID(tokenized, keyID)
+}
+
+// TokenizeWithTweak encrypts plaintext with the primary key using tweak (see
+// fpe.TweakableFPE).
+func (r *RotatingFPE) TokenizeWithTweak(plaintext string, tweak []byte) (string, error) {
+	return r.primary.TokenizeWithTweak(plaintext, tweak)
+}
+
+// DetokenizeWithTweak decrypts a token issued by TokenizeWithTweak with the
+// same tweak, choosing the key as Detokenize does.
+func (r *RotatingFPE) DetokenizeWithTweak(tokenized string, tweak []byte) (string, error) {
+	if len(r.hints) == 0 {
+		return r.primary.DetokenizeWithTweak(tokenized, tweak)
+	}
+	keyID, err := r.KeyID(tokenized)
+	if err != nil {
+		return "", err
+	}
+	tokenizer, err := r.tokenizer(keyID)
+	if err != nil {
+		return "", err
+	}
+	return tokenizer.DetokenizeWithTweak(tokenized, tweak)
+}
+
+// DetokenizeWithKeyID decrypts a token issued by the enabled key with the
+// given ID.
+func (r *RotatingFPE) DetokenizeWithKeyID(tokenized string, keyID uint32) (string, error) {
+	tokenizer, err := r.tokenizer(keyID)
+	if err != nil {
+		return "", err
+	}
+	return tokenizer.Detokenize(tokenized)
+}
+
+// Retokenize migrates a token issued by the enabled key with the given ID to
+// the primary key: it returns the token Tokenize gives for the same plaintext.
+// The plaintext never leaves the call. For self-identifying tokens, KeyID
+// gives the key ID.
+func (r *RotatingFPE) Retokenize(tokenized string, keyID uint32) (string, error) {
+	plaintext, err := r.DetokenizeWithKeyID(tokenized, keyID)
+	if err != nil {
+		return "", err
+	}
+	return r.Tokenize(plaintext)
+}
+
+// tokenizer returns the primitive of the enabled key with the given ID.
+func (r *RotatingFPE) tokenizer(keyID uint32) (fpe.TweakableFPE, error) {
+	tokenizer, ok := r.tokenizers[keyID]
+	if !ok {
+		return nil, fmt.Errorf("no enabled key with ID %d in keyset", keyID)
+	}
+	return tokenizer, nil
+}
+
+// Verify that RotatingFPE implements fpe.TweakableFPE
+var _ fpe.TweakableFPE = (*RotatingFPE)(nil)
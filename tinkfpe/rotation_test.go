@@ -0,0 +1,318 @@
+package tinkfpe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/proto/tink_go_proto"
+	"github.com/vdparikh/fpe"
+	"github.com/vdparikh/fpe/proto/fpe_go_proto"
+)
+
+// TestRotatingFPE verifies that tokens issued before a rotation can be
+// detokenized with their key ID and migrated to the new primary key.
+func TestRotatingFPE(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	manager := keyset.NewManager()
+	oldKeyID, err := manager.Add(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+	if err := manager.SetPrimary(oldKeyID); err != nil {
+		t.Fatalf("Failed to set primary key: %v", err)
+	}
+	handle, err := manager.Handle()
+	if err != nil {
+		t.Fatalf("Failed to get keyset handle: %v", err)
+	}
+
+	tweak := []byte("customer.ssn")
+	opts := []fpe.Option{fpe.WithSSN()}
+	before, err := NewRotating(handle, tweak, opts...)
+	if err != nil {
+		t.Fatalf("NewRotating failed: %v", err)
+	}
+	if before.PrimaryKeyID() != oldKeyID {
+		t.Errorf("Expected primary key ID %d, got %d", oldKeyID, before.PrimaryKeyID())
+	}
+	ssn := "123-45-6789"
+	oldToken, err := before.Tokenize(ssn)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+
+	// Rotate the keyset
+	newKeyID, err := manager.Add(KeyTemplate())
+	if err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+	if err := manager.SetPrimary(newKeyID); err != nil {
+		t.Fatalf("Failed to set primary key: %v", err)
+	}
+	if handle, err = manager.Handle(); err != nil {
+		t.Fatalf("Failed to get keyset handle: %v", err)
+	}
+
+	after, err := NewRotating(handle, tweak, opts...)
+	if err != nil {
+		t.Fatalf("NewRotating failed after rotation: %v", err)
+	}
+	newToken, err := after.Tokenize(ssn)
+	if err != nil {
+		t.Fatalf("Tokenize failed after rotation: %v", err)
+	}
+	if newToken == oldToken {
+		t.Errorf("Expected the new primary key to give a new token, got %s", newToken)
+	}
+
+	detokenized, err := after.DetokenizeWithKeyID(oldToken, oldKeyID)
+	if err != nil {
+		t.Fatalf("DetokenizeWithKeyID failed: %v", err)
+	}
+	if detokenized != ssn {
+		t.Errorf("Expected %s with the old key, got %s", ssn, detokenized)
+	}
+	if detokenized, err := after.Detokenize(newToken); err != nil || detokenized != ssn {
+		t.Errorf("Expected Detokenize to use the primary key: got %s (%v)", detokenized, err)
+	}
+
+	migrated, err := after.Retokenize(oldToken, oldKeyID)
+	if err != nil {
+		t.Fatalf("Retokenize failed: %v", err)
+	}
+	if migrated != newToken {
+		t.Errorf("Expected Retokenize to give %s, got %s", newToken, migrated)
+	}
+
+	// Disabled keys are no longer available
+	if err := manager.Disable(oldKeyID); err != nil {
+		t.Fatalf("Failed to disable key: %v", err)
+	}
+	if handle, err = manager.Handle(); err != nil {
+		t.Fatalf("Failed to get keyset handle: %v", err)
+	}
+	disabled, err := NewRotating(handle, tweak, opts...)
+	if err != nil {
+		t.Fatalf("NewRotating failed with a disabled key: %v", err)
+	}
+	if _, err := disabled.DetokenizeWithKeyID(oldToken, oldKeyID); err == nil {
+		t.Error("Expected DetokenizeWithKeyID to reject a disabled key")
+	}
+	if _, err := disabled.Retokenize(oldToken, oldKeyID); err == nil {
+		t.Error("Expected Retokenize to reject a disabled key")
+	}
+}
+
+// TestRotatingFPEKeyHints verifies that tokens of keys with a key hint carry
+// it and that Detokenize picks the key from it.
+func TestRotatingFPEKeyHints(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	hintTemplate := func(hint string, position uint32) *tink_go_proto.KeyTemplate {
+		template, err := KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+			Alphabet: "0123456789",
+			KeyHint:  &fpe_go_proto.FpeKeyHint{Value: hint, Position: position, Fill: "0"},
+		})
+		if err != nil {
+			t.Fatalf("KeyTemplateWithParams failed: %v", err)
+		}
+		return template
+	}
+
+	manager := keyset.NewManager()
+	oldKeyID, err := manager.Add(hintTemplate("1", 0))
+	if err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+	if err := manager.SetPrimary(oldKeyID); err != nil {
+		t.Fatalf("Failed to set primary key: %v", err)
+	}
+	handle, err := manager.Handle()
+	if err != nil {
+		t.Fatalf("Failed to get keyset handle: %v", err)
+	}
+
+	// NewV2 writes the hint of the primary key as well
+	single, err := NewV2(handle, []byte("account"))
+	if err != nil {
+		t.Fatalf("NewV2 failed: %v", err)
+	}
+	account := "004512345678"
+	oldToken, err := single.Tokenize(account)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if len(oldToken) != len(account) || oldToken[0] != '1' {
+		t.Errorf("Expected a token with the leading key hint 1, got %s", oldToken)
+	}
+	if _, err := New(handle, []byte("account")); err == nil {
+		t.Error("Expected New to reject a key with a key hint")
+	}
+
+	newKeyID, err := manager.Add(hintTemplate("2", 0))
+	if err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+	if err := manager.SetPrimary(newKeyID); err != nil {
+		t.Fatalf("Failed to set primary key: %v", err)
+	}
+	if handle, err = manager.Handle(); err != nil {
+		t.Fatalf("Failed to get keyset handle: %v", err)
+	}
+
+	primitive, err := NewRotating(handle, []byte("account"))
+	if err != nil {
+		t.Fatalf("NewRotating failed: %v", err)
+	}
+	newToken, err := primitive.Tokenize(account)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if newToken[0] != '2' {
+		t.Errorf("Expected a token with the leading key hint 2, got %s", newToken)
+	}
+	for token, keyID := range map[string]uint32{oldToken: oldKeyID, newToken: newKeyID} {
+		if id, err := primitive.KeyID(token); err != nil || id != keyID {
+			t.Errorf("KeyID(%s) = %d (%v), expected %d", token, id, err, keyID)
+		}
+		if detokenized, err := primitive.Detokenize(token); err != nil || detokenized != account {
+			t.Errorf("Detokenize(%s) = %s (%v), expected %s", token, detokenized, err, account)
+		}
+	}
+	if _, err := primitive.Detokenize("3" + oldToken[1:]); err == nil {
+		t.Error("Expected Detokenize to reject an unknown key hint")
+	}
+	if _, err := primitive.DetokenizeWithKeyID(oldToken, newKeyID); err == nil {
+		t.Error("Expected DetokenizeWithKeyID to reject a token with another key's hint")
+	}
+
+	keyID, err := primitive.KeyID(oldToken)
+	if err != nil {
+		t.Fatalf("KeyID failed: %v", err)
+	}
+	if migrated, err := primitive.Retokenize(oldToken, keyID); err != nil || migrated != newToken {
+		t.Errorf("Retokenize(%s) = %s (%v), expected %s", oldToken, migrated, err, newToken)
+	}
+
+	tenantToken, err := primitive.TokenizeWithTweak(account, []byte("tenant"))
+	if err != nil {
+		t.Fatalf("TokenizeWithTweak failed: %v", err)
+	}
+	if detokenized, err := primitive.DetokenizeWithTweak(tenantToken, []byte("tenant")); err != nil || detokenized != account {
+		t.Errorf("DetokenizeWithTweak(%s) = %s (%v), expected %s", tenantToken, detokenized, err, account)
+	}
+
+	// Hints of a keyset must agree in position and differ in value
+	for _, template := range []*tink_go_proto.KeyTemplate{hintTemplate("2", 1), hintTemplate("1", 0)} {
+		m := keyset.NewManager()
+		keyID, err := m.Add(hintTemplate("1", 0))
+		if err != nil {
+			t.Fatalf("Failed to add key: %v", err)
+		}
+		if err := m.SetPrimary(keyID); err != nil {
+			t.Fatalf("Failed to set primary key: %v", err)
+		}
+		if _, err := m.Add(template); err != nil {
+			t.Fatalf("Failed to add key: %v", err)
+		}
+		h, err := m.Handle()
+		if err != nil {
+			t.Fatalf("Failed to get keyset handle: %v", err)
+		}
+		if _, err := NewRotating(h, []byte("account")); err == nil {
+			t.Error("Expected NewRotating to reject inconsistent key hints")
+		}
+	}
+
+	// A keyset cannot mix keys with and without a key hint, in either order
+	for _, templates := range [][]*tink_go_proto.KeyTemplate{
+		{hintTemplate("1", 0), KeyTemplate()},
+		{KeyTemplate(), hintTemplate("1", 0)},
+	} {
+		m := keyset.NewManager()
+		for _, template := range templates {
+			keyID, err := m.Add(template)
+			if err != nil {
+				t.Fatalf("Failed to add key: %v", err)
+			}
+			if err := m.SetPrimary(keyID); err != nil {
+				t.Fatalf("Failed to set primary key: %v", err)
+			}
+		}
+		h, err := m.Handle()
+		if err != nil {
+			t.Fatalf("Failed to get keyset handle: %v", err)
+		}
+		if _, err := NewRotating(h, []byte("account")); err == nil {
+			t.Error("Expected NewRotating to reject a keyset mixing keys with and without key hints")
+		}
+	}
+}
+
+// TestRotatingFPEKeyHintFormat verifies that the key hint takes over a data
+// character, so hinted tokens keep the length and format of the key.
+func TestRotatingFPEKeyHintFormat(t *testing.T) {
+	if _, err := getOrRegisterKeyManager(); err != nil {
+		t.Fatalf("Failed to register KeyManager: %v", err)
+	}
+
+	const template = "###-##-####"
+	format := fpe.MustParseFormat(template)
+	manager := keyset.NewManager()
+	var keyIDs []uint32
+	for _, hint := range []string{"8", "9"} {
+		keyTemplate, err := KeyTemplateWithParams(32, &fpe_go_proto.FpeParams{
+			Format:  template,
+			KeyHint: &fpe_go_proto.FpeKeyHint{Value: hint, Fill: "0"},
+		})
+		if err != nil {
+			t.Fatalf("KeyTemplateWithParams failed: %v", err)
+		}
+		keyID, err := manager.Add(keyTemplate)
+		if err != nil {
+			t.Fatalf("Failed to add key: %v", err)
+		}
+		if err := manager.SetPrimary(keyID); err != nil {
+			t.Fatalf("Failed to set primary key: %v", err)
+		}
+		keyIDs = append(keyIDs, keyID)
+	}
+	handle, err := manager.Handle()
+	if err != nil {
+		t.Fatalf("Failed to get keyset handle: %v", err)
+	}
+	primitive, err := NewRotating(handle, []byte("ssn"))
+	if err != nil {
+		t.Fatalf("NewRotating failed: %v", err)
+	}
+
+	ssn := "012-34-5678"
+	tokenized, err := primitive.Tokenize(ssn)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	if len(tokenized) != len(ssn) || tokenized[0] != '9' {
+		t.Errorf("Expected a token of %d characters with the leading key hint 9, got %s", len(ssn), tokenized)
+	}
+	if err := format.Match(tokenized); err != nil {
+		t.Errorf("Token %s does not match the key's format: %v", tokenized, err)
+	}
+	if keyID, err := primitive.KeyID(tokenized); err != nil || keyID != keyIDs[1] {
+		t.Errorf("KeyID(%s) = %d (%v), expected %d", tokenized, keyID, err, keyIDs[1])
+	}
+	if detokenized, err := primitive.Detokenize(tokenized); err != nil || detokenized != ssn {
+		t.Errorf("Detokenize(%s) = %s (%v), expected %s", tokenized, detokenized, err, ssn)
+	}
+
+	// The spare position of the value must hold the fill
+	_, err = primitive.Tokenize("123-45-6789")
+	if want := `value must hold the spare characters "0" at position 0`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected Tokenize to reject a value without the fill with %q, got %v", want, err)
+	}
+}
@@ -33,11 +33,19 @@ func ensureKeyManagerRegistered() *KeyManager {
 // getOrRegisterKeyManager gets the KeyManager, registering it if necessary.
 // This is a safer version that checks if registration is needed.
 func getOrRegisterKeyManager() (*KeyManager, error) {
-	keyManager := NewKeyManager()
+	return getOrRegister(NewKeyManager())
+}
+
+// getOrRegisterFF31KeyManager gets the FF3-1 KeyManager, registering it if necessary.
+func getOrRegisterFF31KeyManager() (*KeyManager, error) {
+	return getOrRegister(NewFF31KeyManager())
+}
 
+// getOrRegister registers keyManager unless a manager for its type URL is already registered.
+func getOrRegister(keyManager *KeyManager) (*KeyManager, error) {
 	// Check if this type URL is already supported
 	// If it is, the KeyManager is already registered
-	_, err := registry.GetKeyManager(FPEKeyTypeURL)
+	_, err := registry.GetKeyManager(keyManager.TypeURL())
 	if err == nil {
 		// Already registered, return a new instance (they're stateless)
 		return keyManager, nil
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/vdparikh/fpe"
 )
 
 // WycheproofTestSuite represents the top-level structure of a Wycheproof test file
@@ -30,6 +32,7 @@ type WycheproofTestCase struct {
 	Key        string `json:"key"`   // Hex-encoded
 	Tweak      string `json:"tweak"` // Hex-encoded (empty string = empty tweak)
 	Plaintext  string `json:"plaintext"`
+	Alphabet   string `json:"alphabet,omitempty"`   // Optional, overrides alphabet detection
 	Ciphertext string `json:"ciphertext,omitempty"` // Optional, for valid tests
 	Result     string `json:"result"`               // "valid", "invalid", "acceptable"
 }
@@ -48,6 +51,28 @@ func TestWycheproofVectors(t *testing.T) {
 		t.Fatalf("Failed to load Wycheproof test suite: %v", err)
 	}
 
+	runWycheproofSuite(t, keyManager, suite)
+}
+
+// TestWycheproofFF31Vectors runs the Wycheproof-style FF3-1 test suite
+func TestWycheproofFF31Vectors(t *testing.T) {
+	// Get or register the FF3-1 KeyManager (safe for multiple test files)
+	keyManager, err := getOrRegisterFF31KeyManager()
+	if err != nil {
+		t.Fatalf("Failed to register FF3-1 KeyManager: %v", err)
+	}
+
+	// Load test suite
+	suite, err := loadWycheproofTestSuiteFile("wycheproof_ff3_1_vectors.json")
+	if err != nil {
+		t.Fatalf("Failed to load Wycheproof FF3-1 test suite: %v", err)
+	}
+
+	runWycheproofSuite(t, keyManager, suite)
+}
+
+// runWycheproofSuite runs every test group of suite against keys of keyManager's type
+func runWycheproofSuite(t *testing.T, keyManager *KeyManager, suite *WycheproofTestSuite) {
 	t.Logf("Running Wycheproof test suite: %s (version %s)", suite.Algorithm, suite.GeneratorVersion)
 	t.Logf("Total test groups: %d, Total tests: %d", len(suite.TestGroups), suite.NumberOfTests)
 
@@ -126,7 +151,7 @@ func runWycheproofTest(t *testing.T, keyManager *KeyManager, testCase Wycheproof
 	}
 
 	// Create keyset handle
-	handle, err := createKeysetHandleFromKey(key)
+	handle, err := createKeysetHandleWithType(keyManager.TypeURL(), key)
 	if err != nil {
 		if testCase.Result == "invalid" {
 			// Expected to fail - invalid key
@@ -136,8 +161,20 @@ func runWycheproofTest(t *testing.T, keyManager *KeyManager, testCase Wycheproof
 		return "fail"
 	}
 
+	// Vectors with an explicit alphabet (e.g. the radix-36 NIST samples) fix it
+	// instead of relying on alphabet detection
+	var opts []fpe.Option
+	if testCase.Alphabet != "" {
+		alphabet, err := fpe.NewAlphabet(testCase.Alphabet)
+		if err != nil {
+			t.Errorf("TC%d: Invalid alphabet: %v", testCase.TCID, err)
+			return "fail"
+		}
+		opts = append(opts, fpe.WithAlphabet(alphabet))
+	}
+
 	// Create FPE primitive
-	fpePrimitive, err := New(handle, tweak)
+	fpePrimitive, err := New(handle, tweak, opts...)
 	if err != nil {
 		if testCase.Result == "invalid" {
 			// Expected to fail
@@ -195,8 +232,7 @@ func runValidTest(t *testing.T, testCase WycheproofTestCase, fpePrimitive interf
 	if testCase.Ciphertext != "" && tokenized != testCase.Ciphertext {
 		t.Errorf("TC%d: Ciphertext mismatch. Expected: %s, Got: %s",
 			testCase.TCID, testCase.Ciphertext, tokenized)
-		// Don't fail - ciphertext might vary by implementation
-		// Just log it
+		return "fail"
 	}
 
 	// Verify format preservation
@@ -232,13 +268,6 @@ func runValidTest(t *testing.T, testCase WycheproofTestCase, fpePrimitive interf
 		return "fail"
 	}
 
-	// If ciphertext is specified, verify it matches
-	if testCase.Ciphertext != "" && tokenized != testCase.Ciphertext {
-		// Log but don't fail - ciphertext might vary by implementation
-		t.Logf("TC%d: Ciphertext differs from expected (implementation may vary): expected %s, got %s",
-			testCase.TCID, testCase.Ciphertext, tokenized)
-	}
-
 	return "pass"
 }
 
@@ -316,11 +345,16 @@ func runAcceptableTest(t *testing.T, testCase WycheproofTestCase, fpePrimitive i
 	return "pass"
 }
 
-// loadWycheproofTestSuite loads the Wycheproof test suite from JSON
+// loadWycheproofTestSuite loads the FF1 Wycheproof test suite from JSON
 func loadWycheproofTestSuite() (*WycheproofTestSuite, error) {
-	testDataPath := filepath.Join("testdata", "wycheproof_ff1_vectors.json")
+	return loadWycheproofTestSuiteFile("wycheproof_ff1_vectors.json")
+}
+
+// loadWycheproofTestSuiteFile loads a Wycheproof test suite from the named JSON file in testdata
+func loadWycheproofTestSuiteFile(name string) (*WycheproofTestSuite, error) {
+	testDataPath := filepath.Join("testdata", name)
 	if _, err := os.Stat(testDataPath); os.IsNotExist(err) {
-		testDataPath = filepath.Join("..", "testdata", "wycheproof_ff1_vectors.json")
+		testDataPath = filepath.Join("..", "testdata", name)
 	}
 
 	data, err := os.ReadFile(testDataPath)
@@ -0,0 +1,421 @@
+package fpe
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// Tokenizer performs format-preserving tokenization over a fixed format.
+// Unlike the alphabet detection used by FF1.Tokenize, the format is fixed when
+// the Tokenizer is created, so Detokenize inverts Tokenize given only the token.
+// It implements FPEv2.
+type Tokenizer struct {
+	cipher subtle.Cipher
+	config *config
+}
+
+// NewTokenizer creates a Tokenizer on top of a subtle cipher (FF1 or FF3-1).
+// Without options the alphabet is AlphabetAlphanumeric.
+func NewTokenizer(cipher subtle.Cipher, opts ...Option) (*Tokenizer, error) {
+	if cipher == nil {
+		return nil, fmt.Errorf("cipher cannot be nil")
+	}
+	c, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	tweaked := c.revealPrefix+c.revealSuffix > 0
+	switch c.mode {
+	case modeEmail, modeIP, modeIBAN, modePhone, modeDictionary:
+		tweaked = true
+	}
+	if tweaked {
+		if _, ok := cipher.(subtle.TweakableCipher); !ok {
+			return nil, fmt.Errorf("reveal, email, IP, IBAN, phone and dictionary options require a subtle.TweakableCipher, got %T", cipher)
+		}
+	}
+	return &Tokenizer{cipher: cipher, config: c}, nil
+}
+
+// NewFF1Tokenizer creates a Tokenizer that uses FF1 with the given key and tweak.
+func NewFF1Tokenizer(key, tweak []byte, opts ...Option) (*Tokenizer, error) {
+	ff1, err := subtle.NewFF1(key, tweak)
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenizer(ff1, opts...)
+}
+
+// NewFF31Tokenizer creates a Tokenizer that uses FF3-1 with the given key and 7-byte tweak.
+func NewFF31Tokenizer(key, tweak []byte, opts ...Option) (*Tokenizer, error) {
+	ff31, err := subtle.NewFF31(key, tweak)
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenizer(ff31, opts...)
+}
+
+// Alphabet returns the alphabet used for data characters, or nil if the
+// Tokenizer was created with WithCharacterClasses, WithFormat, WithDate,
+// WithEmail, WithIP, WithUUID, WithSSN, WithIBAN, WithPhone, WithDictionary,
+// WithRegex or WithEncoding.
+func (t *Tokenizer) Alphabet() *Alphabet {
+	return t.config.alphabet
+}
+
+// Tokenize encrypts the data characters of plaintext, preserving every
+// character outside the alphabet (or outside every character class) in place.
+func (t *Tokenizer) Tokenize(plaintext string) (string, error) {
+	tokenized, err := t.transform(plaintext, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to tokenize: %w", err)
+	}
+	return tokenized, nil
+}
+
+// Detokenize decrypts a value produced by Tokenize. No knowledge of the
+// original plaintext is required.
+func (t *Tokenizer) Detokenize(tokenized string) (string, error) {
+	plaintext, err := t.transform(tokenized, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to detokenize: %w", err)
+	}
+	return plaintext, nil
+}
+
+// TokenizeWithTweak is Tokenize with tweak in place of the tweak the
+// Tokenizer was created with, so that one Tokenizer can serve many tenants or
+// columns. The tweak must suit the cipher: FF3-1 tweaks are exactly 7 bytes
+// and FF1 tweaks at most subtle.FF1MaxTweakSize bytes.
+func (t *Tokenizer) TokenizeWithTweak(plaintext string, tweak []byte) (string, error) {
+	u, err := t.withTweak(tweak)
+	if err != nil {
+		return "", fmt.Errorf("failed to tokenize: %w", err)
+	}
+	return u.Tokenize(plaintext)
+}
+
+// DetokenizeWithTweak decrypts a value produced by TokenizeWithTweak with the
+// same tweak.
+func (t *Tokenizer) DetokenizeWithTweak(tokenized string, tweak []byte) (string, error) {
+	u, err := t.withTweak(tweak)
+	if err != nil {
+		return "", fmt.Errorf("failed to detokenize: %w", err)
+	}
+	return u.Detokenize(tokenized)
+}
+
+// withTweak returns a copy of t whose cipher uses tweak.
+func (t *Tokenizer) withTweak(tweak []byte) (*Tokenizer, error) {
+	c, ok := t.cipher.(subtle.TweakableCipher)
+	if !ok {
+		return nil, fmt.Errorf("per-call tweaks require a subtle.TweakableCipher, got %T", t.cipher)
+	}
+	if size := c.TweakSize(); size > 0 && len(tweak) != size {
+		return nil, fmt.Errorf("invalid tweak size: %d bytes (cipher requires %d)", len(tweak), size)
+	}
+	return &Tokenizer{cipher: &tweakedCipher{cipher: c, tweak: tweak}, config: t.config}, nil
+}
+
+// transform tokenizes (encrypt) or detokenizes s. Only the data characters
+// chosen by layout are changed; everything else is copied from s.
+func (t *Tokenizer) transform(s string, encrypt bool) (string, error) {
+	switch t.config.mode {
+	case modeDate:
+		return t.transformDate(s, encrypt)
+	case modeEmail:
+		return t.transformEmail(s, encrypt)
+	case modeIP:
+		return t.transformIP(s, encrypt)
+	case modeUUID:
+		return t.transformUUID(s, encrypt)
+	case modeSSN:
+		return t.transformSSN(s, encrypt)
+	case modeIBAN:
+		return t.transformIBAN(s, encrypt)
+	case modePhone:
+		return t.transformPhone(s, encrypt)
+	case modeDictionary:
+		return t.transformDictionary(s, encrypt)
+	case modeRegex:
+		return t.transformRegex(s, encrypt)
+	case modeEncoding:
+		return t.transformEncoded(s, encrypt)
+	}
+
+	out := []rune(s)
+	positions, classes, check, err := t.layout(out)
+	if err != nil {
+		return "", err
+	}
+	if t.config.marker != nil {
+		if positions, classes, err = t.takeMarker(out, positions, classes, encrypt); err != nil {
+			return "", err
+		}
+	}
+
+	digits := positions
+	if t.config.luhn != LuhnNone {
+		check = luhnCheck(digits, check)
+	}
+
+	// Split off the check characters; they are recomputed for the result
+	var checkPositions []int
+	var checkClasses []*Alphabet
+	if alg := t.config.checkDigit; alg != nil {
+		n := len(positions) - alg.Size()
+		if n < 1 {
+			return "", fmt.Errorf("value has %d data characters, %v needs a payload and %d check characters", len(positions), alg, alg.Size())
+		}
+		positions, checkPositions = positions[:n], positions[n:]
+		classes, checkClasses = classes[:n], classes[n:]
+		want, err := alg.Compute(string(revealed(out, positions)))
+		if err != nil {
+			return "", err
+		}
+		if want != string(revealed(out, checkPositions)) {
+			return "", fmt.Errorf("value does not pass the %v check", alg)
+		}
+	}
+
+	// Leave the revealed prefix and suffix in the clear and bind the hidden
+	// middle to them through the tweak.
+	c := t.cipher
+	prefix, suffix := t.config.revealPrefix, t.config.revealSuffix
+	if prefix+suffix > 0 {
+		if len(positions) < prefix+suffix {
+			return "", fmt.Errorf("value has %d data characters, cannot reveal %d leading and %d trailing", len(positions), prefix, suffix)
+		}
+		c = bindCipher(c.(subtle.TweakableCipher), revealed(out, positions[:prefix]), revealed(out, positions[len(positions)-suffix:]))
+		positions = positions[prefix : len(positions)-suffix]
+		classes = classes[prefix : len(classes)-suffix]
+		if err := checkDomain(t.cipher, classes); err != nil {
+			return "", fmt.Errorf("revealing %d leading and %d trailing characters leaves too few hidden characters: %w", prefix, suffix, err)
+		}
+	}
+
+	if t.config.luhn != LuhnNone {
+		if t.config.luhn == LuhnInvalid && !encrypt && len(positions) > 0 {
+			shiftDigit(out, positions[len(positions)-1], -1)
+		}
+		// Cycle walking only stays within the Luhn-valid numbers if it starts there
+		if len(digits) == 0 || !luhnValid(out, digits) {
+			return "", fmt.Errorf("value does not pass the Luhn check")
+		}
+	}
+
+	data := make([]rune, len(positions))
+	for i, p := range positions {
+		data[i] = out[p]
+	}
+
+	for {
+		if t.config.alphabet != nil {
+			data, err = cipherAlphabet(c, t.config.alphabet, data, encrypt)
+		} else {
+			data, err = cipherClasses(c, classes, data, encrypt)
+		}
+		if err != nil {
+			return "", err
+		}
+		for i, p := range positions {
+			out[p] = data[i]
+		}
+		if err := t.writeCheckDigits(out, digits, checkPositions, checkClasses); err != nil {
+			return "", err
+		}
+
+		// Cycle walk until the result passes the same checks as the input: a
+		// format with optional groups must read it like s (see Format.dataLayout)
+		// and WithLuhn may require a valid check digit.
+		if check == nil || check(out) {
+			if t.config.luhn == LuhnInvalid && encrypt {
+				shiftDigit(out, positions[len(positions)-1], 1)
+			}
+			if t.config.marker != nil && encrypt {
+				t.putMarker(out)
+			}
+			return string(out), nil
+		}
+	}
+}
+
+// layout returns the positions of the data characters of s and the alphabet
+// of each. If check is not nil, a result is only valid when check accepts it.
+func (t *Tokenizer) layout(s []rune) (positions []int, classes []*Alphabet, check func([]rune) bool, err error) {
+	switch {
+	case t.config.format != nil:
+		return t.config.format.dataLayout(s)
+	case len(t.config.classes) > 0:
+		for i, r := range s {
+			if class, ok := t.classify(r); ok {
+				positions = append(positions, i)
+				classes = append(classes, class)
+			}
+		}
+	default:
+		for i, r := range s {
+			if t.config.alphabet.Contains(r) {
+				positions = append(positions, i)
+				classes = append(classes, t.config.alphabet)
+			}
+		}
+	}
+	return positions, classes, nil, nil
+}
+
+// writeCheckDigits computes the check characters of the data characters of s
+// at positions (payload followed by check characters) and writes them to
+// checkPositions, which must each accept them by checkClasses.
+func (t *Tokenizer) writeCheckDigits(s []rune, positions, checkPositions []int, checkClasses []*Alphabet) error {
+	alg := t.config.checkDigit
+	if alg == nil {
+		return nil
+	}
+	check, err := alg.Compute(string(revealed(s, positions[:len(positions)-len(checkPositions)])))
+	if err != nil {
+		return err
+	}
+	runes := []rune(check)
+	if len(runes) != len(checkPositions) {
+		return fmt.Errorf("%v returned %d check characters, expected %d", alg, len(runes), len(checkPositions))
+	}
+	for i, r := range runes {
+		if !checkClasses[i].Contains(r) {
+			return fmt.Errorf("%v check character %q is not a valid data character at its position", alg, r)
+		}
+		s[checkPositions[i]] = r
+	}
+	return nil
+}
+
+// luhnCheck extends check to also require that the digits at positions pass
+// the Luhn check.
+func luhnCheck(positions []int, check func([]rune) bool) func([]rune) bool {
+	return func(s []rune) bool {
+		return luhnValid(s, positions) && (check == nil || check(s))
+	}
+}
+
+// bitAlphabet is the radix-2 alphabet that bit strings are encrypted over.
+const bitAlphabet = "01"
+
+// cipherAlphabet encrypts (or decrypts) data, a string of characters of alphabet,
+// directly over the radix of the alphabet.
+func cipherAlphabet(c subtle.Cipher, alphabet *Alphabet, data []rune, encrypt bool) ([]rune, error) {
+	numerals, err := alphabet.Encode(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if encrypt {
+		numerals, err = c.Encrypt(numerals, alphabet.String())
+	} else {
+		numerals, err = c.Decrypt(numerals, alphabet.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	result, err := alphabet.Decode(numerals)
+	if err != nil {
+		return nil, err
+	}
+	return []rune(result), nil
+}
+
+// checkDomain returns an error if the characters of classes can take fewer
+// values than c accepts.
+func checkDomain(c subtle.Cipher, classes []*Alphabet) error {
+	bounded, ok := c.(interface{ MinDomainSize() int })
+	if !ok {
+		return nil
+	}
+	domain := big.NewInt(1)
+	for _, class := range classes {
+		domain.Mul(domain, big.NewInt(int64(class.Radix())))
+	}
+	if domain.Cmp(big.NewInt(int64(bounded.MinDomainSize()))) < 0 {
+		return fmt.Errorf("domain size too small: %d characters have %s possible values (minimum %d required for security)", len(classes), domain.String(), bounded.MinDomainSize())
+	}
+	return nil
+}
+
+// bindCipher returns c with a tweak derived from c's own tweak and parts, so
+// that its output is bound to them. Every component is length-prefixed so that
+// no two lists of parts give the same tweak. Ciphers with a fixed tweak size
+// (FF3-1) get a SHA-256 digest of that, truncated to size.
+func bindCipher(c subtle.TweakableCipher, parts ...[]byte) subtle.Cipher {
+	var tweak []byte
+	for _, part := range append([][]byte{c.Tweak()}, parts...) {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(part)))
+		tweak = append(tweak, length[:]...)
+		tweak = append(tweak, part...)
+	}
+	if size := c.TweakSize(); size > 0 {
+		digest := sha256.Sum256(tweak)
+		tweak = digest[:size]
+	}
+	return &tweakedCipher{cipher: c, tweak: tweak}
+}
+
+// revealed returns the characters of s at positions, UTF-8 encoded.
+func revealed(s []rune, positions []int) []byte {
+	runes := make([]rune, len(positions))
+	for i, p := range positions {
+		runes[i] = s[p]
+	}
+	return []byte(string(runes))
+}
+
+// tweakedCipher runs a TweakableCipher with a fixed per-call tweak in place
+// of its own. It is a TweakableCipher itself, whose own tweak is that tweak.
+type tweakedCipher struct {
+	cipher subtle.TweakableCipher
+	tweak  []byte
+}
+
+// Encrypt implements subtle.Cipher.
+func (c *tweakedCipher) Encrypt(plaintext []uint16, alphabet string) ([]uint16, error) {
+	return c.cipher.EncryptWithTweak(plaintext, alphabet, c.tweak)
+}
+
+// Decrypt implements subtle.Cipher.
+func (c *tweakedCipher) Decrypt(ciphertext []uint16, alphabet string) ([]uint16, error) {
+	return c.cipher.DecryptWithTweak(ciphertext, alphabet, c.tweak)
+}
+
+// EncryptWithTweak implements subtle.TweakableCipher.
+func (c *tweakedCipher) EncryptWithTweak(plaintext []uint16, alphabet string, tweak []byte) ([]uint16, error) {
+	return c.cipher.EncryptWithTweak(plaintext, alphabet, tweak)
+}
+
+// DecryptWithTweak implements subtle.TweakableCipher.
+func (c *tweakedCipher) DecryptWithTweak(ciphertext []uint16, alphabet string, tweak []byte) ([]uint16, error) {
+	return c.cipher.DecryptWithTweak(ciphertext, alphabet, tweak)
+}
+
+// Tweak returns the per-call tweak.
+func (c *tweakedCipher) Tweak() []byte {
+	return c.tweak
+}
+
+// TweakSize returns the tweak size of the underlying cipher.
+func (c *tweakedCipher) TweakSize() int {
+	return c.cipher.TweakSize()
+}
+
+// MinDomainSize returns the minimum domain size of the underlying cipher.
+func (c *tweakedCipher) MinDomainSize() int {
+	return c.cipher.MinDomainSize()
+}
+
+// Verify that Tokenizer implements TweakableFPE and tweakedCipher implements
+// subtle.TweakableCipher
+var (
+	_ TweakableFPE           = (*Tokenizer)(nil)
+	_ subtle.TweakableCipher = (*tweakedCipher)(nil)
+)
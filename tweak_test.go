@@ -0,0 +1,124 @@
+package fpe
+
+import (
+	"testing"
+
+	"github.com/vdparikh/fpe/subtle"
+)
+
+// TestTokenizeWithTweak verifies that a per-call tweak gives the tokens of a
+// Tokenizer created with that tweak, including for options that derive their
+// own tweaks.
+func TestTokenizeWithTweak(t *testing.T) {
+	testCases := []struct {
+		name      string
+		plaintext string
+		opts      []Option
+	}{
+		{"Alphabet", "123-45-6789", []Option{WithAlphabet(AlphabetNumeric)}},
+		{"RevealSuffix", "4111111111111111", []Option{WithAlphabet(AlphabetNumeric), WithRevealSuffix(4)}},
+		{"Email", "alice@example.com", []Option{WithEmail(EmailKeepDomain)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shared, err := NewFF1Tokenizer(testKey, []byte("default"), tc.opts...)
+			if err != nil {
+				t.Fatalf("NewFF1Tokenizer failed: %v", err)
+			}
+			for _, tenant := range []string{"tenant-a", "tenant-b"} {
+				dedicated, err := NewFF1Tokenizer(testKey, []byte(tenant), tc.opts...)
+				if err != nil {
+					t.Fatalf("NewFF1Tokenizer failed: %v", err)
+				}
+				want, err := dedicated.Tokenize(tc.plaintext)
+				if err != nil {
+					t.Fatalf("Tokenize failed: %v", err)
+				}
+				tokenized, err := shared.TokenizeWithTweak(tc.plaintext, []byte(tenant))
+				if err != nil {
+					t.Fatalf("TokenizeWithTweak failed: %v", err)
+				}
+				if tokenized != want {
+					t.Errorf("Tweak %s: expected %s, got %s", tenant, want, tokenized)
+				}
+				detokenized, err := shared.DetokenizeWithTweak(tokenized, []byte(tenant))
+				if err != nil {
+					t.Fatalf("DetokenizeWithTweak failed: %v", err)
+				}
+				if detokenized != tc.plaintext {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", tc.plaintext, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestTokenizeWithTweakValidation verifies the tweak length limits of FF1 and
+// FF3-1.
+func TestTokenizeWithTweakValidation(t *testing.T) {
+	ff31, err := NewFF31Tokenizer(testKey, []byte("tweak77"), WithAlphabet(AlphabetNumeric))
+	if err != nil {
+		t.Fatalf("NewFF31Tokenizer failed: %v", err)
+	}
+	if _, err := ff31.TokenizeWithTweak("1234567890", []byte("tenant-1")); err == nil {
+		t.Error("Expected FF3-1 to reject an 8-byte tweak")
+	}
+	if _, err := ff31.TokenizeWithTweak("1234567890", []byte("tenant1")); err != nil {
+		t.Errorf("FF3-1 rejected a 7-byte tweak: %v", err)
+	}
+
+	ff1, err := NewFF1Tokenizer(testKey, nil, WithAlphabet(AlphabetNumeric))
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+	if _, err := ff1.TokenizeWithTweak("1234567890", make([]byte, subtle.FF1MaxTweakSize+1)); err == nil {
+		t.Error("Expected FF1 to reject a tweak longer than FF1MaxTweakSize")
+	}
+	if _, err := ff1.TokenizeWithTweak("1234567890", make([]byte, subtle.FF1MaxTweakSize)); err != nil {
+		t.Errorf("FF1 rejected a tweak of FF1MaxTweakSize bytes: %v", err)
+	}
+	if _, err := NewFF1(testKey, make([]byte, subtle.FF1MaxTweakSize+1)); err == nil {
+		t.Error("Expected NewFF1 to reject a tweak longer than FF1MaxTweakSize")
+	}
+}
+
+// TestFF1TokenizeWithTweak verifies the per-call tweak methods of FF1 and FF31
+// without options.
+func TestFF1TokenizeWithTweak(t *testing.T) {
+	plaintext := "123-45-6789"
+
+	ff1, err := NewFF1(testKey, []byte("default"))
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+	dedicated, err := NewFF1(testKey, []byte("tenant-a"))
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+	want, err := dedicated.Tokenize(plaintext)
+	if err != nil {
+		t.Fatalf("Tokenize failed: %v", err)
+	}
+	tokenized, err := ff1.TokenizeWithTweak(plaintext, []byte("tenant-a"))
+	if err != nil || tokenized != want {
+		t.Errorf("TokenizeWithTweak = %s (%v), expected %s", tokenized, err, want)
+	}
+	if detokenized, err := ff1.DetokenizeWithTweak(tokenized, []byte("tenant-a"), plaintext, ""); err != nil || detokenized != plaintext {
+		t.Errorf("DetokenizeWithTweak = %s (%v), expected %s", detokenized, err, plaintext)
+	}
+
+	ff31, err := NewFF31(testKey, []byte("tweak77"))
+	if err != nil {
+		t.Fatalf("NewFF31 failed: %v", err)
+	}
+	tokenized, err = ff31.TokenizeWithTweak(plaintext, []byte("tenant1"))
+	if err != nil {
+		t.Fatalf("FF31 TokenizeWithTweak failed: %v", err)
+	}
+	if detokenized, err := ff31.DetokenizeWithTweak(tokenized, []byte("tenant1"), plaintext, ""); err != nil || detokenized != plaintext {
+		t.Errorf("FF31 DetokenizeWithTweak = %s (%v), expected %s", detokenized, err, plaintext)
+	}
+	if _, err := ff31.TokenizeWithTweak(plaintext, []byte("tenant")); err == nil {
+		t.Error("Expected FF31 to reject a 6-byte tweak")
+	}
+}
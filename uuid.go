@@ -0,0 +1,159 @@
+package fpe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// uuidHyphens are the positions of the hyphens in the canonical UUID form.
+var uuidHyphens = []int{8, 13, 18, 23}
+
+// WithUUID makes the Tokenizer treat every value as a UUID (RFC 4122 / RFC
+// 9562) in canonical form ("550e8400-e29b-41d4-a716-446655440000"), braced
+// form ("{550e8400-...}") or as 32 hex digits without hyphens. Tokens are
+// UUIDs in the same form:
+//
+//   - The version nibble and the variant bits are kept, so a version 4 UUID
+//     tokenizes to a version 4 UUID; only the remaining bits (122 for RFC 4122
+//     UUIDs) are encrypted.
+//   - The letter case of the value is kept. Values with both upper and
+//     lowercase hex letters are rejected. Uppercase values are cycle walked to
+//     tokens that contain a letter, so that Detokenize can read the case back.
+func WithUUID() Option {
+	return func(c *config) error {
+		return c.setMode(modeUUID)
+	}
+}
+
+// transformUUID tokenizes (encrypt) or detokenizes s under WithUUID.
+func (t *Tokenizer) transformUUID(s string, encrypt bool) (string, error) {
+	hex, wrap, err := parseUUID(s)
+	if err != nil {
+		return "", err
+	}
+	upper := strings.ToLower(hex) != hex
+	if upper && strings.ToUpper(hex) != hex {
+		return "", fmt.Errorf("UUID %q mixes upper and lowercase letters", s)
+	}
+
+	numerals, err := AlphabetHex.Encode(strings.ToLower(hex))
+	if err != nil {
+		return "", err
+	}
+
+	// Walk uppercase values until the result has a letter to carry the case.
+	// The value itself has one, so this is a permutation of such values.
+	for {
+		if numerals, err = t.cipherUUID(numerals, encrypt); err != nil {
+			return "", err
+		}
+		result, err := AlphabetHex.Decode(numerals)
+		if err != nil {
+			return "", err
+		}
+		if !upper {
+			return wrap(result), nil
+		}
+		if hasHexLetter(result) {
+			return wrap(strings.ToUpper(result)), nil
+		}
+	}
+}
+
+// cipherUUID encrypts or decrypts the 32 hex numerals of a UUID, keeping the
+// version and variant bits.
+func (t *Tokenizer) cipherUUID(numerals []uint16, encrypt bool) ([]uint16, error) {
+	bits := make([]uint16, 0, 4*len(numerals))
+	for _, n := range numerals {
+		for shift := 3; shift >= 0; shift-- {
+			bits = append(bits, (n>>shift)&1)
+		}
+	}
+
+	// Bits 48-51 are the version; the variant is the leading bits of octet 8,
+	// up to and including its first zero bit (at most three bits).
+	fixed := map[int]bool{48: true, 49: true, 50: true, 51: true}
+	for i := 64; i < 67; i++ {
+		fixed[i] = true
+		if bits[i] == 0 {
+			break
+		}
+	}
+
+	var free []uint16
+	for i, b := range bits {
+		if !fixed[i] {
+			free = append(free, b)
+		}
+	}
+	var err error
+	if encrypt {
+		free, err = t.cipher.Encrypt(free, bitAlphabet)
+	} else {
+		free, err = t.cipher.Decrypt(free, bitAlphabet)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range bits {
+		if !fixed[i] {
+			bits[i], free = free[0], free[1:]
+		}
+	}
+
+	out := make([]uint16, len(numerals))
+	for i := range out {
+		out[i] = bits[4*i]<<3 | bits[4*i+1]<<2 | bits[4*i+2]<<1 | bits[4*i+3]
+	}
+	return out, nil
+}
+
+// parseUUID returns the 32 hex digits of s and a function that writes 32 hex
+// digits back in the form of s.
+func parseUUID(s string) (hex string, wrap func(string) string, err error) {
+	braced := len(s) == 38 && s[0] == '{' && s[37] == '}'
+	body := s
+	if braced {
+		body = s[1:37]
+	}
+
+	hyphenated := len(body) == 36
+	switch {
+	case hyphenated:
+		for _, p := range uuidHyphens {
+			if body[p] != '-' {
+				return "", nil, fmt.Errorf("UUID %q has no hyphen at position %d", s, p)
+			}
+		}
+		hex = strings.ReplaceAll(body, "-", "")
+	case len(body) == 32 && !braced:
+		hex = body
+	default:
+		return "", nil, fmt.Errorf("value %q is not a UUID: expected 36 characters, 38 with braces or 32 hex digits", s)
+	}
+	if len(hex) != 32 {
+		return "", nil, fmt.Errorf("UUID %q has misplaced hyphens", s)
+	}
+	for _, r := range hex {
+		if !AlphabetHex.Contains(r) && !AlphabetHexUpper.Contains(r) {
+			return "", nil, fmt.Errorf("UUID %q contains invalid character %q", s, r)
+		}
+	}
+
+	wrap = func(hex string) string {
+		if !hyphenated {
+			return hex
+		}
+		out := hex[:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:]
+		if braced {
+			out = "{" + out + "}"
+		}
+		return out
+	}
+	return hex, wrap, nil
+}
+
+// hasHexLetter reports whether the hex digits s contain a letter.
+func hasHexLetter(s string) bool {
+	return strings.ContainsAny(s, "abcdefABCDEF")
+}
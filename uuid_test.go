@@ -0,0 +1,160 @@
+package fpe
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestUUIDTokenization verifies that tokens keep the form, case, version and
+// variant of the UUID and that they round-trip.
+func TestUUIDTokenization(t *testing.T) {
+	uuids := []string{
+		"550e8400-e29b-41d4-a716-446655440000",
+		"{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}",
+		"f47ac10b58cc4372a5670e02b2c3d479",
+		"01890a5d-ac96-774b-bcce-b302099a8057", // version 7
+		"00000000-0000-0000-0000-000000000000", // nil UUID
+		"6ba7b810-9dad-11d1-c0b4-00c04fd430c8", // Microsoft variant
+	}
+
+	tokenizers := map[string]func() (*Tokenizer, error){
+		"FF1": func() (*Tokenizer, error) {
+			return NewFF1Tokenizer(testKey, []byte("uuid"), WithUUID())
+		},
+		"FF3-1": func() (*Tokenizer, error) {
+			return NewFF31Tokenizer(testKey, []byte("tweak77"), WithUUID())
+		},
+	}
+	for name, newTokenizer := range tokenizers {
+		t.Run(name, func(t *testing.T) {
+			tokenizer, err := newTokenizer()
+			if err != nil {
+				t.Fatalf("Failed to create tokenizer: %v", err)
+			}
+
+			for _, uuid := range uuids {
+				tokenized, err := tokenizer.Tokenize(uuid)
+				if err != nil {
+					t.Fatalf("Tokenize(%s) failed: %v", uuid, err)
+				}
+				if tokenized == uuid || len(tokenized) != len(uuid) {
+					t.Errorf("Bad token for %s: %s", uuid, tokenized)
+				}
+				hex, _, err := parseUUID(tokenized)
+				if err != nil {
+					t.Fatalf("Token %s is not a UUID in the same form: %v", tokenized, err)
+				}
+				plainHex, _, _ := parseUUID(uuid)
+				if hex[12] != plainHex[12] {
+					t.Errorf("Version not preserved: %s -> %s", uuid, tokenized)
+				}
+				if variantOf(hex[16]) != variantOf(plainHex[16]) {
+					t.Errorf("Variant not preserved: %s -> %s", uuid, tokenized)
+				}
+				upper := strings.ToLower(uuid) != uuid
+				if upper && strings.ToUpper(tokenized) != tokenized || !upper && strings.ToLower(tokenized) != tokenized {
+					t.Errorf("Case not preserved: %s -> %s", uuid, tokenized)
+				}
+				for i := range uuid {
+					if !isHexDigit(uuid[i]) && uuid[i] != tokenized[i] {
+						t.Errorf("Form not preserved: %s -> %s", uuid, tokenized)
+						break
+					}
+				}
+
+				detokenized, err := tokenizer.Detokenize(tokenized)
+				if err != nil {
+					t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+				}
+				if detokenized != uuid {
+					t.Errorf("Round-trip failed: %s -> %s -> %s", uuid, tokenized, detokenized)
+				}
+			}
+		})
+	}
+}
+
+// TestUUIDTokenizationUpperCase verifies that an uppercase UUID whose token
+// would have no letters is walked to a token that keeps its case.
+func TestUUIDTokenizationUpperCase(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, []byte("uuid"), WithUUID())
+	if err != nil {
+		t.Fatalf("Failed to create tokenizer: %v", err)
+	}
+
+	// The lowercase value of a token without letters
+	letterless := "12345678-9012-4345-8789-012345678901"
+	value, err := tokenizer.Detokenize(letterless)
+	if err != nil {
+		t.Fatalf("Detokenize(%s) failed: %v", letterless, err)
+	}
+	uuid := strings.ToUpper(value)
+	if uuid == value {
+		t.Fatalf("Expected %s to contain a letter", value)
+	}
+
+	tokenized, err := tokenizer.Tokenize(uuid)
+	if err != nil {
+		t.Fatalf("Tokenize(%s) failed: %v", uuid, err)
+	}
+	if !hasHexLetter(tokenized) || strings.ToUpper(tokenized) != tokenized {
+		t.Errorf("Expected an uppercase token with a letter for %s, got %s", uuid, tokenized)
+	}
+	detokenized, err := tokenizer.Detokenize(tokenized)
+	if err != nil {
+		t.Fatalf("Detokenize(%s) failed: %v", tokenized, err)
+	}
+	if detokenized != uuid {
+		t.Errorf("Round-trip failed: %s -> %s -> %s", uuid, tokenized, detokenized)
+	}
+}
+
+// TestUUIDValidation verifies that malformed UUIDs are rejected.
+func TestUUIDValidation(t *testing.T) {
+	tokenizer, err := NewFF1Tokenizer(testKey, nil, WithUUID())
+	if err != nil {
+		t.Fatalf("NewFF1Tokenizer failed: %v", err)
+	}
+
+	for _, uuid := range []string{
+		"",
+		"550e8400-e29b-41d4-a716-44665544000",    // too short
+		"550e8400-e29b-41d4-a716-4466554400000",  // too long
+		"550e8400e-29b-41d4-a716-446655440000",   // misplaced hyphen
+		"550e8400-e29b-41d4-a716-44665544000g",   // not hex
+		"550e8400-E29B-41d4-a716-446655440000",   // mixed case
+		"{550e8400e29b41d4a716446655440000}",     // braced without hyphens
+		"(550e8400-e29b-41d4-a716-446655440000)", // wrong brackets
+	} {
+		if _, err := tokenizer.Tokenize(uuid); err == nil {
+			t.Errorf("Expected Tokenize(%q) to fail", uuid)
+		}
+	}
+
+	if _, err := NewFF1Tokenizer(testKey, nil, WithUUID(), WithRevealPrefix(4)); err == nil {
+		t.Error("Expected WithUUID and reveal options to be exclusive")
+	}
+	if _, err := NewFF1Tokenizer(testKey, nil, WithUUID(), WithIP(IPFull)); err == nil {
+		t.Error("Expected WithUUID and WithIP to be exclusive")
+	}
+}
+
+// variantOf returns the variant bits of the variant hex digit of a UUID.
+func variantOf(digit byte) string {
+	v := strings.IndexByte("0123456789abcdef", digit|0x20)
+	switch {
+	case v < 8:
+		return "0"
+	case v < 12:
+		return "10"
+	case v < 14:
+		return "110"
+	default:
+		return "111"
+	}
+}
+
+// isHexDigit reports whether b is a hex digit in either case.
+func isHexDigit(b byte) bool {
+	return strings.IndexByte("0123456789abcdefABCDEF", b) >= 0
+}